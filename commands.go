@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"qr-code-boost/src/apikey"
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/mongo"
+	"qr-code-boost/src/postgres"
+	"qr-code-boost/src/qrcode"
+	"qr-code-boost/src/repositories"
+	"qr-code-boost/src/storage"
+	"strings"
+	"time"
+)
+
+// runCommand executa as tarefas administrativas disponíveis pela linha de
+// comando, ex.: `./main reconcile --dry-run`.
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "reconcile":
+		return runReconcile(cfg, args[1:])
+	case "apikey":
+		return runAPIKey(cfg, args[1:])
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "mongo-migrate":
+		return runMongoMigrate(cfg, args[1:])
+	default:
+		return fmt.Errorf("comando desconhecido: %s", args[0])
+	}
+}
+
+func runReconcile(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "apenas lista as inconsistências, sem corrigi-las")
+	gracePeriod := flags.Duration("grace-period", time.Hour, "ignora imagens mais novas que este período")
+	flags.Parse(args)
+
+	mongoClient, err := mongo.ConnectMongoDB(cfg.Mongo)
+
+	if err != nil {
+		return err
+	}
+
+	defer mongoClient.Disconnect(context.Background())
+
+	imageStorage, err := storage.New(cfg.Storage)
+
+	if err != nil {
+		return err
+	}
+
+	// O reconcile não consulta usuários nem scans, por isso só o repositório de QR Codes
+	qrCodeService := qrcode.NewQRCodeService(
+		repositories.NewMongoQRCodeRepository(mongoClient.Database(cfg.Mongo.Database)),
+		nil,
+		nil,
+		imageStorage,
+		cfg,
+	)
+
+	report, err := qrCodeService.Reconcile(context.Background(), qrcode.ReconcileOptions{
+		GracePeriod: *gracePeriod,
+		DryRun:      *dryRun,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	output, _ := json.MarshalIndent(report, "", " ")
+	fmt.Println(string(output))
+
+	return nil
+}
+
+func runAPIKey(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: apikey create|revoke [opções]")
+	}
+
+	postgresClient, err := postgres.ConnectionPostgres(cfg.Postgres.URL)
+
+	if err != nil {
+		return err
+	}
+
+	defer postgresClient.Close()
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("apikey create", flag.ExitOnError)
+		userId := flags.String("user", "", "ID do usuário dono da chave")
+		name := flags.String("name", "default", "nome para identificar a chave")
+		scopes := flags.String("scopes", "", "escopos separados por vírgula, ex.: admin")
+		flags.Parse(args[1:])
+
+		if *userId == "" {
+			return fmt.Errorf("--user é obrigatório")
+		}
+
+		var scopeList []string
+		if *scopes != "" {
+			scopeList = strings.Split(*scopes, ",")
+		}
+
+		plainKey, created, err := apikey.Create(context.Background(), *userId, *name, scopeList, postgresClient)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("API key %s criada. Guarde o valor abaixo, ele não será exibido novamente:\n%s\n", created.Id, plainKey)
+		return nil
+	case "revoke":
+		flags := flag.NewFlagSet("apikey revoke", flag.ExitOnError)
+		id := flags.String("id", "", "ID da chave a revogar")
+		flags.Parse(args[1:])
+
+		if *id == "" {
+			return fmt.Errorf("--id é obrigatório")
+		}
+
+		if err := apikey.Revoke(context.Background(), *id, postgresClient); err != nil {
+			return err
+		}
+
+		fmt.Printf("API key %s revogada.\n", *id)
+		return nil
+	default:
+		return fmt.Errorf("subcomando desconhecido: apikey %s", args[0])
+	}
+}
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: migrate up|down|status [opções]")
+	}
+
+	postgresClient, err := postgres.ConnectionPostgres(cfg.Postgres.URL)
+
+	if err != nil {
+		return err
+	}
+
+	defer postgresClient.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := postgres.MigrateUp(postgresClient)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d migration(s) aplicada(s).\n", len(applied))
+		return nil
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "quantidade de migrations a reverter")
+		flags.Parse(args[1:])
+
+		reverted, err := postgres.MigrateDown(postgresClient, *steps)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d migration(s) revertida(s).\n", len(reverted))
+		return nil
+	case "status":
+		status, err := postgres.GetMigrationStatus(postgresClient)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range status {
+			state := "pendente"
+			if migration.AppliedAt != nil {
+				state = "aplicada em " + migration.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", migration.Version, migration.Name, state)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("subcomando desconhecido: migrate %s", args[0])
+	}
+}
+
+func runMongoMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: mongo-migrate up|status")
+	}
+
+	mongoClient, err := mongo.ConnectMongoDB(cfg.Mongo)
+
+	if err != nil {
+		return err
+	}
+
+	defer mongoClient.Disconnect(context.Background())
+
+	switch args[0] {
+	case "up":
+		applied, err := mongo.Migrate(mongoClient.Database(cfg.Mongo.Database))
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d migration(s) do mongo aplicada(s).\n", len(applied))
+		return nil
+	case "status":
+		status, err := mongo.GetMigrationStatus(mongoClient.Database(cfg.Mongo.Database))
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range status {
+			state := "pendente"
+			if migration.AppliedAt != nil {
+				state = "aplicada em " + migration.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%03d_%s\t%s\n", migration.Version, migration.Name, state)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("subcomando desconhecido: mongo-migrate %s", args[0])
+	}
+}
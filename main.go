@@ -1,17 +1,34 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/clientip"
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/health"
+	"qr-code-boost/src/i18n"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/metrics"
+	"qr-code-boost/src/middlewares"
 	"qr-code-boost/src/mongo"
 	"qr-code-boost/src/postgres"
 	"qr-code-boost/src/qrcode"
-
-	"github.com/joho/godotenv"
+	"qr-code-boost/src/ratelimit"
+	"qr-code-boost/src/repositories"
+	"qr-code-boost/src/scan"
+	"qr-code-boost/src/storage"
+	"qr-code-boost/src/tracing"
+	"qr-code-boost/src/user"
+	"qr-code-boost/src/workspace"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 
 	docs "qr-code-boost/docs"
 
@@ -19,17 +36,10 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func init() {
-	// Carrega as variáveis de .env para o ambiente
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Arquivo .env não encontrado, usando variáveis de ambiente do sistema")
-	}
-}
-
 // @title           QR Code Boost API
 // @version         1.0
 // @description     This is an API for managing users and QR codes.
+// @description     Errors are application/problem+json (RFC 7807) with a stable "code"; "detail" and field messages follow Accept-Language (pt-BR or en).
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -41,53 +51,320 @@ func init() {
 
 // @host      localhost:8080
 // @BasePath  /
+
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Bearer token emitido pelo provedor de identidade
 func main() {
-	router := gin.Default()
+	// Carrega .env, CONFIG_FILE e variáveis de ambiente e valida tudo de uma vez
+	cfg, configErr := config.Load()
+
+	if configErr != nil {
+		fatal("Configuração inválida", configErr)
+	}
+
+	logging.Setup(cfg.Log)
+
+	// Validado por config.Load
+	defaultLanguage, _ := i18n.Parse(cfg.DefaultLanguage)
+	i18n.SetDefault(defaultLanguage)
+
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1:]); err != nil {
+			fatal("Erro ao executar o comando", err)
+		}
+		return
+	}
+
+	// Cancelado no SIGINT/SIGTERM para iniciar o encerramento
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, tracingErr := tracing.Setup(ctx, cfg.Tracing)
+
+	if tracingErr != nil {
+		fatal("Erro ao configurar o tracing", tracingErr)
+	}
+
+	router := gin.New()
 	docs.SwaggerInfo.BasePath = "/"
 
-	postgresClient, postgresConnectionErr := postgres.ConnectionPostgres()
+	// O IP do cliente é resolvido pelo pacote clientip; o gin não deve confiar
+	// em X-Forwarded-For por conta própria.
+	router.SetTrustedProxies(nil)
+
+	trustedProxies, trustedProxiesErr := clientip.LoadTrustedProxies(cfg.TrustedProxies, cfg.TrustedProxyFiles)
+
+	if trustedProxiesErr != nil {
+		fatal("Erro ao carregar os proxies confiáveis", trustedProxiesErr)
+	}
+
+	cloudflareRanges, cloudflareRangesErr := clientip.LoadPrefixesFiles(cfg.CloudflareIPFiles)
+
+	if cloudflareRangesErr != nil {
+		fatal("Erro ao carregar as faixas do Cloudflare", cloudflareRangesErr)
+	}
+
+	ipResolver := clientip.NewResolver(trustedProxies, cloudflareRanges)
+	router.Use(
+		middlewares.RequestIdMiddleware(),
+		middlewares.LanguageMiddleware(),
+		middlewares.TracingMiddleware(),
+		ipResolver.Middleware(),
+		middlewares.LoggerMiddleware(),
+		middlewares.MetricsMiddleware(),
+		middlewares.RecoveryMiddleware(),
+	)
+
+	postgresClient, postgresConnectionErr := postgres.ConnectionPostgres(cfg.Postgres.URL)
 
 	if postgresConnectionErr != nil {
-		log.Fatal("Erro ao se conectar com o postgresql: ", postgresConnectionErr)
+		fatal("Erro ao se conectar com o postgresql", postgresConnectionErr)
+	}
+
+	if cfg.MigrateOnStartup {
+		_, migrationErr := postgres.MigrateUp(postgresClient)
+
+		if migrationErr != nil {
+			fatal("Erro ao aplicar migrations do postgresql", migrationErr)
+		}
 	}
 
-	mongoClient, mongoConnectionErr := mongo.ConnectMongoDB()
+	mongoClient, mongoConnectionErr := mongo.ConnectMongoDB(cfg.Mongo)
 
 	if mongoConnectionErr != nil {
-		log.Fatal("Erro ao se conectar com o mongodb: ", mongoConnectionErr)
+		fatal("Erro ao se conectar com o mongodb", mongoConnectionErr)
 	}
 
-	mongo.CreateIndexes(mongoClient)
+	mongoDatabase := mongoClient.Database(cfg.Mongo.Database)
 
-	router.Static("/images", "./static/images")
+	_, mongoMigrationErr := mongo.Migrate(mongoDatabase)
 
-	stats, _ := mongo.GetDBStats(mongoClient)
+	if mongoMigrationErr != nil {
+		fatal("Erro ao aplicar migrations do mongodb", mongoMigrationErr)
+	}
 
-	json, errJson := json.MarshalIndent(stats, "", " ")
-	if errJson != nil {
-		fmt.Println("Erro ao identar os stats")
+	imageStorage, storageErr := storage.New(cfg.Storage)
+
+	if storageErr != nil {
+		fatal("Erro ao configurar o storage de imagens", storageErr)
+	}
+
+	// Somente o storage local precisa ser servido pela própria API
+	if localStorage, ok := imageStorage.(*storage.LocalStorage); ok {
+		router.Static("/images", localStorage.Dir)
 	}
 
-	fmt.Println("---------------------------")
-	fmt.Println(string(json))
-	fmt.Println("---------------------------")
+	qrCodeRepository := repositories.NewMongoQRCodeRepository(mongoDatabase)
+	scanRepository := repositories.NewMongoScanRepository(mongoDatabase)
+
+	scanIngester := scan.NewIngester(scanRepository, scan.IngesterOptions{
+		QueueSize:      cfg.ScanIngestion.QueueSize,
+		BatchSize:      cfg.ScanIngestion.BatchSize,
+		FlushInterval:  cfg.ScanIngestion.FlushInterval,
+		EnqueueTimeout: cfg.ScanIngestion.EnqueueTimeout,
+	})
+
+	scanService := scan.NewScanService(
+		scanRepository,
+		scanIngester,
+		scan.NewVisitorHasher(repositories.NewMongoVisitorSaltRepository(mongoDatabase)),
+		cfg.NearScans.DefaultMaxDistance,
+	)
+
+	qrCodeService := qrcode.NewQRCodeService(
+		qrCodeRepository,
+		repositories.NewPostgresUserRepository(postgresClient),
+		scanService,
+		imageStorage,
+		cfg,
+	)
+
+	// Invalida o cache de slugs com as alterações feitas pelas outras réplicas
+	go qrcode.WatchSlugCache(ctx, qrCodeRepository, qrCodeService.Cache)
 
 	qrCodeController := &qrcode.QRCodeController{
-		MongoClient:    mongoClient,
+		Service:        qrCodeService,
+		MongoDatabase:  mongoDatabase,
 		PostgresClient: postgresClient,
 	}
 
-	qrcode.QRCodesRouter(router, qrCodeController)
+	jwtVerifier, jwtErr := auth.NewJWTVerifierFromConfig(cfg.JWT)
+
+	if jwtErr != nil {
+		fatal("Erro ao configurar a autenticação JWT", jwtErr)
+	}
+
+	// Os CIDRs já foram validados em config.Load
+	allowlist, _ := clientip.ParsePrefixes(cfg.AllowedCIDRs)
+	denylist, _ := clientip.ParsePrefixes(cfg.DeniedCIDRs)
+
+	internalOnlyMiddleware := middlewares.InternalOnlyMiddleware(ipResolver, allowlist, denylist)
+	authMiddleware := middlewares.AuthMiddleware(postgresClient, jwtVerifier)
+
+	var accessGuards, apiRateLimits []gin.HandlerFunc
+	apiGuards := []gin.HandlerFunc{internalOnlyMiddleware}
+
+	var redisClient *ratelimit.GoRedisClient
+
+	if cfg.RateLimit.Enabled {
+		var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+		if cfg.RateLimit.RedisURL != "" {
+			var redisErr error
+			redisClient, redisErr = ratelimit.NewGoRedisClient(cfg.RateLimit.RedisURL)
+
+			if redisErr != nil {
+				fatal("Erro ao configurar o redis do rate limit", redisErr)
+			}
+
+			rateLimitStore = ratelimit.NewRedisStore(redisClient)
+		}
+
+		accessPolicy := ratelimit.Policy{Name: "access", Limit: cfg.RateLimit.Access.Limit, Period: cfg.RateLimit.Access.Period}
+		apiPolicy := ratelimit.Policy{Name: "api", Limit: cfg.RateLimit.API.Limit, Period: cfg.RateLimit.API.Period}
+		apiByIPPolicy := ratelimit.Policy{Name: "api-ip", Limit: cfg.RateLimit.APIByIP.Limit, Period: cfg.RateLimit.APIByIP.Period}
+
+		accessGuards = append(accessGuards, middlewares.RateLimitMiddleware(rateLimitStore, accessPolicy, middlewares.RateLimitByClientIP))
+
+		// O limite por IP roda antes da autenticação, que consulta o banco a
+		// cada chave, inclusive as inválidas; o por API key, depois dela
+		apiGuards = append(apiGuards, middlewares.RateLimitMiddleware(rateLimitStore, apiByIPPolicy, middlewares.RateLimitByClientIP))
+		apiRateLimits = append(apiRateLimits, middlewares.RateLimitMiddleware(rateLimitStore, apiPolicy, middlewares.RateLimitByPrincipal))
+	}
+
+	apiGuards = append(apiGuards, authMiddleware)
+	apiGuards = append(apiGuards, apiRateLimits...)
+
+	qrcode.QRCodesRouter(router, qrCodeController, accessGuards, apiGuards...)
+
+	workspaceController := &workspace.WorkspaceController{
+		PostgresClient: postgresClient,
+	}
+
+	workspace.WorkspacesRouter(router, workspaceController, apiGuards...)
+
+	userController := &user.UserController{
+		PostgresClient: postgresClient,
+	}
+
+	user.UsersRouter(router, userController, apiGuards...)
+
+	healthService := health.NewHealthService(
+		cfg.QueryTimeout,
+		health.MongoCheck(mongoClient),
+		health.PostgresCheck(postgresClient),
+		health.StorageCheck(imageStorage),
+		health.MigrationsCheck(mongoDatabase, postgresClient),
+	)
+
+	healthController := &health.HealthController{
+		Service:      healthService,
+		StatsTimeout: cfg.QueryTimeout,
+		Stats: map[string]health.StatsProvider{
+			"mongo": func(ctx context.Context) (any, error) {
+				return mongo.GetDBStats(ctx, mongoDatabase)
+			},
+			"scanIngester": func(ctx context.Context) (any, error) {
+				return scanIngester.Stats(), nil
+			},
+			"slugCache": func(ctx context.Context) (any, error) {
+				return qrCodeService.Cache.Stats(), nil
+			},
+		},
+	}
+
+	metrics.Registry.MustRegister(
+		scanIngester,
+		qrCodeService.Cache,
+		mongo.NewDBStatsCollector(mongoDatabase, cfg.QueryTimeout),
+		collectors.NewDBStatsCollector(postgresClient, "postgres"),
+	)
+
+	metrics.MetricsRouter(router, internalOnlyMiddleware)
+
+	health.HealthRouter(router, healthController, []gin.HandlerFunc{internalOnlyMiddleware}, apiGuards...)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Valor padrão caso a variável não seja definida
+	port := cfg.Port
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
-	fmt.Printf("\n %sAPI running on http://localhost:%s \n", "\x1b[32m", port)
-	fmt.Printf("\n Docs available on http://localhost:%s/swagger/index.html%s \n \n", port, "\x1b[0m")
-	router.Run(":" + port)
+	serverErr := make(chan error, 1)
+
+	go func() {
+		slog.Info("API iniciada", "addr", server.Addr, "docs", "http://localhost:"+port+"/swagger/index.html")
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("Erro ao iniciar o servidor", err)
+	case <-ctx.Done():
+	}
+
+	stop() // Um segundo sinal encerra o processo na hora
+	slog.Info("Sinal recebido, encerrando a API")
+
+	healthService.SetShuttingDown()
+
+	// A ordem importa: as requisições em andamento ainda enfileiram scans, e a
+	// fila precisa do MongoDB para ser gravada.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Erro ao aguardar as requisições em andamento", logging.Err(err))
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelDrain()
+
+	if err := scanIngester.Close(drainCtx); err != nil {
+		slog.Error("Erro ao esvaziar a fila de scans", logging.Err(err))
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelClose()
+
+	if err := mongoClient.Disconnect(closeCtx); err != nil {
+		slog.Error("Erro ao desconectar do mongodb", logging.Err(err))
+	}
+
+	if err := postgresClient.Close(); err != nil {
+		slog.Error("Erro ao fechar as conexões do postgresql", logging.Err(err))
+	}
+
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			slog.Error("Erro ao fechar a conexão com o redis", logging.Err(err))
+		}
+	}
+
+	// Por último, quando nenhuma requisição ou consulta abre mais spans
+	if err := shutdownTracing(closeCtx); err != nil {
+		slog.Error("Erro ao enviar os spans pendentes", logging.Err(err))
+	}
+
+	slog.Info("API encerrada")
+}
 
+// fatal registra o erro e encerra o processo, como log.Fatal.
+func fatal(message string, err error) {
+	slog.Error(message, logging.Err(err))
+	os.Exit(1)
 }
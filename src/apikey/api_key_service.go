@@ -0,0 +1,146 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/logging"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const keyPrefix = "qrb_"
+
+var ErrInvalidKey = apperror.New(apperror.Unauthorized, "auth.api_key_invalid")
+
+type APIKey struct {
+	Id         string
+	UserId     string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Create gera uma nova chave para o usuário. O valor em texto puro só é
+// devolvido aqui; no banco fica apenas o hash SHA-256.
+func Create(ctx context.Context, userId string, name string, scopes []string, db *sql.DB) (string, APIKey, error) {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+
+	plainKey := keyPrefix + hex.EncodeToString(secret)
+
+	apiKey := APIKey{
+		UserId: userId,
+		Name:   name,
+		Prefix: plainKey[:len(keyPrefix)+8],
+		Scopes: scopes,
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := db.QueryRowContext(ctx, query, userId, name, apiKey.Prefix, hashKey(plainKey), pq.Array(scopes)).Scan(
+		&apiKey.Id,
+		&apiKey.CreatedAt,
+	)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao criar API key", logging.UserId(userId), logging.Err(err))
+		return "", APIKey{}, err
+	}
+
+	return plainKey, apiKey, nil
+}
+
+// FindByKey resolve uma chave em texto puro, ignorando chaves revogadas e
+// usuários removidos.
+func FindByKey(ctx context.Context, plainKey string, db *sql.DB) (APIKey, error) {
+	if !strings.HasPrefix(plainKey, keyPrefix) {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	var apiKey APIKey
+
+	query := `
+		SELECT
+				k.id,
+				k.user_id,
+				k.name,
+				k.prefix,
+				k.scopes,
+				k.created_at,
+				k.last_used_at
+		FROM
+				api_keys k
+				JOIN users u ON u.id = k.user_id
+		WHERE
+				k.key_hash = $1
+				AND k.revoked_at IS NULL
+				AND u.deleted_at IS NULL
+	`
+
+	err := db.QueryRowContext(ctx, query, hashKey(plainKey)).Scan(
+		&apiKey.Id,
+		&apiKey.UserId,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		pq.Array(&apiKey.Scopes),
+		&apiKey.CreatedAt,
+		&apiKey.LastUsedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return APIKey{}, ErrInvalidKey
+		}
+
+		slog.ErrorContext(ctx, "Erro ao buscar API key", logging.Err(err))
+		return APIKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+func TouchLastUsed(ctx context.Context, id string, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func Revoke(ctx context.Context, id string, db *sql.DB) error {
+	result, err := db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+func hashKey(plainKey string) string {
+	hash := sha256.Sum256([]byte(plainKey))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,133 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/postgres/postgrestest"
+)
+
+const userId = "7f1c1b2e-8c5e-4a8a-9d0a-3f7c1e9b2a10"
+
+var keyColumns = []string{"id", "user_id", "name", "prefix", "scopes", "created_at", "last_used_at"}
+
+func TestCreateStoresOnlyTheHash(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("INSERT INTO api_keys").Rows([]string{"id", "created_at"}, []any{"key-1", time.Now()})
+
+	plainKey, apiKey, err := Create(t.Context(), userId, "ci", []string{"qrcode:write"}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(plainKey, keyPrefix) || len(plainKey) != len(keyPrefix)+64 {
+		t.Errorf("plain key = %q", plainKey)
+	}
+
+	if apiKey.Id != "key-1" || apiKey.Prefix != plainKey[:len(keyPrefix)+8] {
+		t.Errorf("api key = %+v", apiKey)
+	}
+
+	hash := sha256.Sum256([]byte(plainKey))
+	args := fake.Calls()[0].Args
+
+	if args[3] != hex.EncodeToString(hash[:]) {
+		t.Errorf("stored hash = %v, want the SHA-256 of the key", args[3])
+	}
+
+	for _, arg := range args {
+		if arg == plainKey {
+			t.Error("plain key sent to the database")
+		}
+	}
+}
+
+func TestCreateGeneratesDistinctKeys(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("INSERT INTO api_keys").Rows([]string{"id", "created_at"}, []any{"key-1", time.Now()})
+	fake.Expect("INSERT INTO api_keys").Rows([]string{"id", "created_at"}, []any{"key-2", time.Now()})
+
+	first, _, _ := Create(t.Context(), userId, "a", nil, db)
+	second, _, _ := Create(t.Context(), userId, "b", nil, db)
+
+	if first == second {
+		t.Errorf("two keys generated with the same value %q", first)
+	}
+}
+
+func TestFindByKey(t *testing.T) {
+	plainKey := keyPrefix + strings.Repeat("ab", 32)
+
+	t.Run("looks up by hash", func(t *testing.T) {
+		db, fake := postgrestest.New(t)
+		fake.Expect("k.key_hash = $1").Rows(keyColumns,
+			[]any{"key-1", userId, "ci", plainKey[:12], "{qrcode:write,stats:read}", time.Now(), nil},
+		)
+
+		apiKey, err := FindByKey(t.Context(), plainKey, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if apiKey.Id != "key-1" || apiKey.UserId != userId || len(apiKey.Scopes) != 2 || apiKey.Scopes[1] != "stats:read" || apiKey.LastUsedAt != nil {
+			t.Errorf("api key = %+v", apiKey)
+		}
+
+		call := fake.Calls()[0]
+		if call.Args[0] != hashKey(plainKey) {
+			t.Errorf("lookup arg = %v, want the hash", call.Args[0])
+		}
+
+		for _, condition := range []string{"revoked_at IS NULL", "deleted_at IS NULL"} {
+			if !strings.Contains(call.Query, condition) {
+				t.Errorf("lookup without %s", condition)
+			}
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		db, fake := postgrestest.New(t)
+		fake.Expect("k.key_hash = $1")
+
+		if _, err := FindByKey(t.Context(), plainKey, db); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("err = %v, want ErrInvalidKey", err)
+		}
+	})
+
+	t.Run("without the prefix", func(t *testing.T) {
+		db, _ := postgrestest.New(t)
+
+		// Nenhuma query é esperada
+		if _, err := FindByKey(t.Context(), "not-a-key", db); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("err = %v, want ErrInvalidKey", err)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db, fake := postgrestest.New(t)
+		fake.Expect("k.key_hash = $1").Error(errors.New("connection refused"))
+
+		if _, err := FindByKey(t.Context(), plainKey, db); err == nil || errors.Is(err, ErrInvalidKey) {
+			t.Errorf("err = %v, want the database error", err)
+		}
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("SET revoked_at = NOW()").RowsAffected(1)
+	fake.Expect("SET revoked_at = NOW()").RowsAffected(0)
+
+	if err := Revoke(t.Context(), "key-1", db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Já revogada
+	if err := Revoke(t.Context(), "key-1", db); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("err = %v, want ErrInvalidKey", err)
+	}
+}
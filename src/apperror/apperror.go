@@ -0,0 +1,127 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"qr-code-boost/src/i18n"
+
+	"golang.org/x/text/language"
+)
+
+// Kind diz como um erro deve ser respondido, sem que os serviços conheçam os
+// status HTTP.
+type Kind int
+
+const (
+	Internal Kind = iota
+	Invalid
+	Unauthorized
+	Forbidden
+	NotFound
+	Conflict
+	Unprocessable
+	TooManyRequests
+	Unavailable
+	Timeout
+	Canceled
+)
+
+// StatusClientClosedRequest não existe no net/http; é o código que o nginx usa
+// quando o cliente desiste antes da resposta.
+const StatusClientClosedRequest = 499
+
+func (k Kind) Status() int {
+	switch k {
+	case Invalid:
+		return http.StatusBadRequest
+	case Unauthorized:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict:
+		return http.StatusConflict
+	case Unprocessable:
+		return http.StatusUnprocessableEntity
+	case TooManyRequests:
+		return http.StatusTooManyRequests
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Canceled:
+		return StatusClientClosedRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Error é um erro esperado das regras de negócio, como um QR Code inexistente.
+// Code identifica a mensagem nos catálogos do pacote i18n, traduzida no idioma
+// da requisição; os demais erros viram um 500 genérico. Err guarda a causa
+// original para os logs sem expô-la na resposta.
+type Error struct {
+	Kind Kind
+	Code string
+	Args []any
+	Err  error
+}
+
+// FieldError é um campo rejeitado, identificado pelo nome usado no JSON.
+type FieldError struct {
+	Field   string `json:"field" example:"link"`
+	Rule    string `json:"rule" example:"url"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message" example:"link deve ser uma URL válida"`
+}
+
+// New recebe o código da mensagem e, se o texto tiver verbos do fmt, os
+// valores que o completam.
+func New(kind Kind, code string, args ...any) *Error {
+	return &Error{Kind: kind, Code: code, Args: args}
+}
+
+// Message traduz a mensagem para o idioma informado.
+func (e *Error) Message(tag language.Tag) string {
+	return i18n.Message(tag, e.Code, e.Args...)
+}
+
+// Error usa o idioma padrão, que também é o dos logs.
+func (e *Error) Error() string {
+	message := e.Message(i18n.Default())
+
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errInternal = New(Internal, "server.internal")
+	errTimeout  = New(Timeout, "server.timeout")
+	errCanceled = New(Canceled, "server.canceled")
+)
+
+// From classifica qualquer erro. Prazos estourados e cancelamentos vindos do
+// contexto da requisição têm respostas próprias em vez de um 500.
+func From(err error) *Error {
+	var appErr *Error
+
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, context.DeadlineExceeded):
+		return errTimeout
+	case errors.Is(err, context.Canceled):
+		return errCanceled
+	}
+
+	return errInternal
+}
@@ -0,0 +1,62 @@
+package apperror
+
+import (
+	"net/http"
+	"qr-code-boost/src/i18n"
+	"qr-code-boost/src/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem é o corpo das respostas de erro, no formato da RFC 7807. Sem um
+// catálogo de tipos, type é sempre about:blank e title o texto do status.
+// Code identifica o erro independentemente do idioma de detail.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Code      string       `json:"code" example:"qrcode.not_found"`
+	Detail    string       `json:"detail" example:"QR Code não encontrado."`
+	Instance  string       `json:"instance" example:"/qr/promo"`
+	RequestId string       `json:"requestId,omitempty" example:"3f0c2a9e7b1d4c58"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Respond registra o erro em c.Errors, de onde o log da requisição e o span o
+// leem, e o escreve como problem+json. Deve ser chamado pelo próprio handler,
+// e não deixado para um middleware externo, para que o middleware de
+// idempotência guarde a resposta certa.
+func Respond(c *gin.Context, err error) {
+	c.Error(err)
+	Write(c, err)
+}
+
+// Write escreve a resposta do erro, no idioma escolhido pelo
+// LanguageMiddleware, e interrompe os próximos handlers.
+func Write(c *gin.Context, err error) {
+	appErr := From(err)
+	status := appErr.Kind.Status()
+	tag := i18n.FromContext(c.Request.Context())
+
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+
+	// O c.IndentedJSON mantém o Content-Type já definido
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", tag.String())
+	c.Abort()
+	c.IndentedJSON(status, Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Code:      appErr.Code,
+		Detail:    appErr.Message(tag),
+		Instance:  c.Request.URL.Path,
+		RequestId: logging.RequestIdFromContext(c.Request.Context()),
+		Errors:    fieldErrors(appErr.Err, tag),
+	})
+}
@@ -0,0 +1,133 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"qr-code-boost/src/i18n"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ptBRTranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+	"golang.org/x/text/language"
+)
+
+// translators traduz as mensagens do validator usado pelo ShouldBindJSON,
+// um por idioma com catálogo.
+var translators = map[language.Tag]ut.Translator{}
+
+// Os erros de validação passam a usar o nome do campo no JSON ("userId") em
+// vez do nome no struct ("UserId"), que é o que o cliente enviou, e ganham as
+// traduções do go-playground.
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if name == "-" {
+			return ""
+		}
+
+		if name == "" {
+			return field.Name
+		}
+
+		return name
+	})
+
+	universal := ut.New(en.New(), en.New(), pt_BR.New())
+
+	english, _ := universal.GetTranslator("en")
+	enTranslations.RegisterDefaultTranslations(validate, english)
+	translators[i18n.English] = english
+
+	portugueseBR, _ := universal.GetTranslator("pt_BR")
+	ptBRTranslations.RegisterDefaultTranslations(validate, portugueseBR)
+	translators[i18n.PortugueseBR] = portugueseBR
+
+	// Ausente nas traduções em português do go-playground
+	validate.RegisterTranslation("required_with", portugueseBR,
+		func(translator ut.Translator) error {
+			return translator.Add("required_with", "{0} é obrigatório quando {1} é informado", true)
+		},
+		func(translator ut.Translator, fieldErr validator.FieldError) string {
+			message, _ := translator.T("required_with", fieldErr.Field(), fieldErr.Param())
+			return message
+		},
+	)
+}
+
+// Validation converte o erro do ShouldBindJSON em um 400. Os campos
+// rejeitados são detalhados na resposta, no idioma da requisição; JSON
+// malformado não tem campos para detalhar.
+func Validation(err error) *Error {
+	return &Error{Kind: Invalid, Code: "request.invalid_body", Err: err}
+}
+
+func fieldErrors(err error, tag language.Tag) []FieldError {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		fields := make([]FieldError, 0, len(validationErrors))
+
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fieldErr),
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: fieldMessage(fieldErr, tag),
+			})
+		}
+
+		return fields
+
+	case errors.As(err, &typeError):
+		kind := typeError.Type.Kind().String()
+
+		return []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Param:   kind,
+			Message: i18n.Message(tag, "validation.type", typeError.Field, kind),
+		}}
+	}
+
+	return nil
+}
+
+// fieldPath remove o nome do DTO do início do namespace
+// ("CreateQRCodeDto.userId" vira "userId").
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+
+	if !found {
+		return fieldErr.Field()
+	}
+
+	return path
+}
+
+// fieldMessage usa o catálogo quando a regra não tem tradução: nesse caso o
+// validator devolve o próprio erro, em inglês e com o nome do struct.
+func fieldMessage(fieldErr validator.FieldError, tag language.Tag) string {
+	translator, ok := translators[tag]
+
+	if ok {
+		if message := fieldErr.Translate(translator); message != fieldErr.Error() {
+			return message
+		}
+	}
+
+	return i18n.Message(tag, "validation.invalid", fieldErr.Field())
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"qr-code-boost/src/logging"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+// KeySet fornece as chaves públicas usadas para validar a assinatura dos JWTs.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (*jose.JSONWebKey, error)
+}
+
+// StaticKeySet é um JWKS fixo, carregado de arquivo ou montado nos testes.
+type StaticKeySet struct {
+	jwks jose.JSONWebKeySet
+}
+
+func NewStaticKeySet(jwks jose.JSONWebKeySet) *StaticKeySet {
+	return &StaticKeySet{jwks: jwks}
+}
+
+func NewKeySetFromFile(path string) (*StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler JWKS %s: %v", path, err)
+	}
+
+	var jwks jose.JSONWebKeySet
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("JWKS inválido em %s: %v", path, err)
+	}
+
+	return NewStaticKeySet(jwks), nil
+}
+
+func (s *StaticKeySet) Key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	return findKey(s.jwks, kid)
+}
+
+// RemoteKeySet busca o JWKS do provedor de identidade e o mantém em cache.
+// Um kid desconhecido força nova busca (rotação de chaves), limitada a uma
+// por minRefreshInterval. Se a atualização falhar, as últimas chaves obtidas
+// continuam valendo, para que uma instabilidade do provedor não derrube a API.
+type RemoteKeySet struct {
+	URL             string
+	RefreshInterval time.Duration
+	HTTPClient      *http.Client
+
+	fetches     singleflight.Group
+	mu          sync.RWMutex
+	jwks        jose.JSONWebKeySet
+	lastFetched time.Time
+	lastAttempt time.Time
+}
+
+const minRefreshInterval = 30 * time.Second
+
+const fetchTimeout = 10 * time.Second
+
+func NewRemoteKeySet(url string, refreshInterval time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		URL:             url,
+		RefreshInterval: refreshInterval,
+		HTTPClient:      &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	s.mu.RLock()
+	jwks, lastFetched, lastAttempt := s.jwks, s.lastFetched, s.lastAttempt
+	s.mu.RUnlock()
+
+	canRefresh := time.Since(lastAttempt) > minRefreshInterval
+
+	if lastFetched.IsZero() || (canRefresh && time.Since(lastFetched) > s.RefreshInterval) {
+		refreshed, err := s.refresh(ctx)
+
+		switch {
+		case err == nil:
+			jwks = refreshed
+		case lastFetched.IsZero():
+			return nil, err
+		default:
+			slog.WarnContext(ctx, "Erro ao atualizar o JWKS, mantendo as chaves anteriores", logging.Err(err))
+		}
+
+		canRefresh = false
+	}
+
+	key, err := findKey(jwks, kid)
+
+	if err != nil && canRefresh {
+		refreshed, fetchErr := s.refresh(ctx)
+
+		if fetchErr != nil {
+			slog.WarnContext(ctx, "Erro ao atualizar o JWKS, mantendo as chaves anteriores", logging.Err(fetchErr))
+			return nil, err
+		}
+
+		return findKey(refreshed, kid)
+	}
+
+	return key, err
+}
+
+// refresh faz a busca sem segurar o lock, para que as validações com as chaves
+// atuais não esperem pela rede; buscas simultâneas são feitas uma só vez.
+func (s *RemoteKeySet) refresh(ctx context.Context) (jose.JSONWebKeySet, error) {
+	result, err, _ := s.fetches.Do(s.URL, func() (any, error) {
+		// A busca é compartilhada com as outras requisições à espera, então não
+		// pode ser cancelada porque o primeiro cliente desconectou
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+
+		jwks, err := s.fetch(fetchCtx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.lastAttempt = time.Now()
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.jwks = jwks
+		s.lastFetched = s.lastAttempt
+
+		return jwks, nil
+	})
+
+	if err != nil {
+		return jose.JSONWebKeySet{}, err
+	}
+
+	return result.(jose.JSONWebKeySet), nil
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var jwks jose.JSONWebKeySet
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+
+	if err != nil {
+		return jwks, err
+	}
+
+	response, err := s.HTTPClient.Do(request)
+
+	if err != nil {
+		return jwks, fmt.Errorf("falha ao buscar JWKS: %v", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return jwks, fmt.Errorf("falha ao buscar JWKS: status %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+
+	if err != nil {
+		return jwks, err
+	}
+
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return jwks, fmt.Errorf("JWKS inválido: %v", err)
+	}
+
+	return jwks, nil
+}
+
+func findKey(jwks jose.JSONWebKeySet, kid string) (*jose.JSONWebKey, error) {
+	var keys []jose.JSONWebKey
+
+	if kid == "" {
+		keys = jwks.Keys
+	} else {
+		keys = jwks.Key(kid)
+	}
+
+	// Sem kid só aceitamos o token se houver uma única chave candidata
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("chave não encontrada no JWKS: %q", kid)
+	}
+
+	return &keys[0], nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// jwksServer serve o JWKS guardado em keys e conta as buscas recebidas.
+type jwksServer struct {
+	keys    atomic.Value
+	fail    atomic.Bool
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...jose.JSONWebKey) (*jwksServer, *httptest.Server) {
+	t.Helper()
+
+	s := &jwksServer{}
+	s.keys.Store(jose.JSONWebKeySet{Keys: keys})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+
+		if s.fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		json.NewEncoder(w).Encode(s.keys.Load())
+	}))
+	t.Cleanup(server.Close)
+
+	return s, server
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	_, oldKey := newSigningKey(t, "key-1")
+	_, newKey := newSigningKey(t, "key-2")
+
+	jwks, server := newJWKSServer(t, oldKey)
+	keySet := NewRemoteKeySet(server.URL, time.Hour)
+
+	if _, err := keySet.Key(t.Context(), "key-1"); err != nil {
+		t.Fatalf("key-1: %v", err)
+	}
+
+	jwks.keys.Store(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{oldKey, newKey}})
+
+	// Dentro do minRefreshInterval um kid desconhecido não gera nova busca
+	if _, err := keySet.Key(t.Context(), "key-2"); err == nil {
+		t.Fatal("key-2 found before the refresh was allowed")
+	}
+
+	keySet.lastAttempt = time.Now().Add(-time.Minute)
+
+	if _, err := keySet.Key(t.Context(), "key-2"); err != nil {
+		t.Fatalf("key-2 after rotation: %v", err)
+	}
+
+	if got := jwks.fetches.Load(); got != 2 {
+		t.Errorf("%d fetches, want 2", got)
+	}
+}
+
+func TestRemoteKeySetKeepsKeysWhenRefreshFails(t *testing.T) {
+	_, key := newSigningKey(t, "key-1")
+
+	jwks, server := newJWKSServer(t, key)
+	keySet := NewRemoteKeySet(server.URL, time.Hour)
+
+	if _, err := keySet.Key(t.Context(), "key-1"); err != nil {
+		t.Fatalf("key-1: %v", err)
+	}
+
+	jwks.fail.Store(true)
+	keySet.lastFetched = time.Now().Add(-2 * time.Hour)
+	keySet.lastAttempt = keySet.lastFetched
+
+	if _, err := keySet.Key(t.Context(), "key-1"); err != nil {
+		t.Fatalf("stale key-1 not served while the provider is down: %v", err)
+	}
+
+	if got := jwks.fetches.Load(); got != 2 {
+		t.Errorf("%d fetches, want 2", got)
+	}
+
+	// A falha também conta para o intervalo mínimo entre buscas
+	if _, err := keySet.Key(t.Context(), "key-1"); err != nil || jwks.fetches.Load() != 2 {
+		t.Errorf("err = %v after %d fetches, want the cached key without a new fetch", err, jwks.fetches.Load())
+	}
+}
+
+func TestRemoteKeySetFailsWithoutKeys(t *testing.T) {
+	jwks, server := newJWKSServer(t)
+	jwks.fail.Store(true)
+
+	if _, err := NewRemoteKeySet(server.URL, time.Hour).Key(t.Context(), "key-1"); err == nil {
+		t.Error("expected an error when the first fetch fails")
+	}
+}
+
+// A busca é compartilhada entre as requisições à espera: a desistência de
+// quem a iniciou não pode derrubar as demais.
+func TestRemoteKeySetFetchIgnoresCallerCancellation(t *testing.T) {
+	_, key := newSigningKey(t, "key-1")
+
+	_, server := newJWKSServer(t, key)
+	keySet := NewRemoteKeySet(server.URL, time.Hour)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := keySet.Key(ctx, "key-1"); err != nil {
+		t.Fatalf("key-1 with a cancelled caller: %v", err)
+	}
+}
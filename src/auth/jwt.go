@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/config"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var ErrInvalidToken = apperror.New(apperror.Unauthorized, "auth.token_invalid")
+
+var allowedAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+type TokenClaims struct {
+	jwt.Claims
+	Scope  string   `json:"scope,omitempty"`
+	Scopes []string `json:"scp,omitempty"`
+}
+
+// AllScopes junta o formato OAuth ("scope" separado por espaços) e o "scp" em lista.
+func (c TokenClaims) AllScopes() []string {
+	scopes := append([]string{}, c.Scopes...)
+	return append(scopes, strings.Fields(c.Scope)...)
+}
+
+type JWTVerifier struct {
+	Keys     KeySet
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// NewJWTVerifierFromConfig usa a URL ou o arquivo JWKS configurado. Sem nenhum
+// dos dois a autenticação por JWT fica desabilitada e o retorno é nil.
+func NewJWTVerifierFromConfig(cfg config.JWTConfig) (*JWTVerifier, error) {
+	var keys KeySet
+
+	switch {
+	case cfg.JWKSURL != "":
+		keys = NewRemoteKeySet(cfg.JWKSURL, 15*time.Minute)
+	case cfg.JWKSFile != "":
+		fileKeys, err := NewKeySetFromFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = fileKeys
+	default:
+		return nil, nil
+	}
+
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, fmt.Errorf("JWT_ISSUER e JWT_AUDIENCE são obrigatórios com o JWKS")
+	}
+
+	return &JWTVerifier{
+		Keys:     keys,
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   time.Minute,
+	}, nil
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, rawToken string) (TokenClaims, error) {
+	token, err := jwt.ParseSigned(rawToken, allowedAlgorithms)
+
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if len(token.Headers) != 1 {
+		return TokenClaims{}, ErrInvalidToken
+	}
+
+	key, err := v.Keys.Key(ctx, token.Headers[0].KeyID)
+
+	if err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims TokenClaims
+
+	if err := token.Claims(key.Key, &claims); err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	expected := jwt.Expected{
+		Issuer:      v.Issuer,
+		AnyAudience: jwt.Audience{v.Audience},
+		Time:        time.Now(),
+	}
+
+	if err := claims.ValidateWithLeeway(expected, v.Leeway); err != nil {
+		return TokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Expiry == nil || claims.Subject == "" {
+		return TokenClaims{}, fmt.Errorf("%w: exp e sub são obrigatórios", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	testIssuer   = "https://id.example.com/"
+	testAudience = "qr-code-boost"
+)
+
+func newSigningKey(t *testing.T, kid string) (*ecdsa.PrivateKey, jose.JSONWebKey) {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return private, jose.JSONWebKey{Key: &private.PublicKey, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"}
+}
+
+func signToken(t *testing.T, private *ecdsa.PrivateKey, kid string, claims jwt.Claims) string {
+	t.Helper()
+
+	options := (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: private}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func validClaims() jwt.Claims {
+	return jwt.Claims{
+		Issuer:   testIssuer,
+		Audience: jwt.Audience{testAudience},
+		Subject:  "user-1",
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	}
+}
+
+func TestJWTVerifierVerify(t *testing.T) {
+	private, public := newSigningKey(t, "key-1")
+	otherPrivate, _ := newSigningKey(t, "key-1")
+
+	verifier := &JWTVerifier{
+		Keys:     NewStaticKeySet(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{public}}),
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Leeway:   time.Minute,
+	}
+
+	claims, err := verifier.Verify(t.Context(), signToken(t, private, "key-1", validClaims()))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("subject = %q, want user-1", claims.Subject)
+	}
+
+	expired := validClaims()
+	expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "https://evil.example.com/"
+
+	otherAudience := validClaims()
+	otherAudience.Audience = jwt.Audience{"another-app"}
+
+	withoutExpiry := validClaims()
+	withoutExpiry.Expiry = nil
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"signed by another key", signToken(t, otherPrivate, "key-1", validClaims())},
+		{"unknown kid", signToken(t, private, "key-2", validClaims())},
+		{"expired", signToken(t, private, "key-1", expired)},
+		{"another issuer", signToken(t, private, "key-1", otherIssuer)},
+		{"another audience", signToken(t, private, "key-1", otherAudience)},
+		{"without expiry", signToken(t, private, "key-1", withoutExpiry)},
+		{"malformed", "not.a.token"},
+	}
+
+	for _, tc := range cases {
+		if _, err := verifier.Verify(t.Context(), tc.token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+}
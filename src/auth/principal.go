@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ScopeAdmin = "admin"
+
+const principalKey = "principal"
+
+// Principal é quem está chamando a API, resolvido pelos middlewares de autenticação.
+type Principal struct {
+	UserId   string
+	Scopes   []string
+	Method   string // "api_key" ou "jwt"
+	APIKeyId string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.HasScope(ScopeAdmin)
+}
+
+// CanAccess indica se o principal pode operar sobre um recurso do usuário ownerId.
+// Um principal nil (rota sem autenticação) nunca tem acesso.
+func (p *Principal) CanAccess(ownerId string) bool {
+	return p != nil && (p.IsAdmin() || p.UserId == ownerId)
+}
+
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
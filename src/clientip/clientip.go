@@ -0,0 +1,241 @@
+package clientip
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextKey = "clientIP"
+
+// Resolver descobre o IP real do cliente. Os cabeçalhos de encaminhamento só
+// são considerados quando a conexão vem de um proxy confiável; caso contrário
+// qualquer cliente poderia se passar por um IP interno.
+type Resolver struct {
+	trustedProxies []netip.Prefix
+	// Faixas do Cloudflare: também são confiáveis e são as únicas de quem o
+	// CF-Connecting-IP é aceito.
+	cloudflare []netip.Prefix
+}
+
+func NewResolver(trustedProxies []netip.Prefix, cloudflare []netip.Prefix) *Resolver {
+	return &Resolver{trustedProxies: trustedProxies, cloudflare: cloudflare}
+}
+
+func (r *Resolver) IsTrusted(addr netip.Addr) bool {
+	return Contains(r.trustedProxies, addr) || Contains(r.cloudflare, addr)
+}
+
+// ClientIP retorna um endereço inválido (IsValid() == false) apenas quando
+// nem o RemoteAddr da conexão pode ser interpretado.
+func (r *Resolver) ClientIP(req *http.Request) netip.Addr {
+	peer := parseAddr(req.RemoteAddr)
+
+	if !peer.IsValid() || !r.IsTrusted(peer) {
+		return peer
+	}
+
+	// Um proxy confiável que não é o Cloudflare repassa o CF-Connecting-IP que
+	// o cliente enviou, então o cabeçalho só vale vindo direto do Cloudflare
+	if Contains(r.cloudflare, peer) {
+		if addr := parseAddr(req.Header.Get("CF-Connecting-IP")); addr.IsValid() {
+			return addr
+		}
+	}
+
+	// No X-Forwarded-For cada proxy acrescenta à direita o IP de quem o chamou,
+	// então o cliente é o primeiro endereço não confiável lido da direita.
+	var hops []string
+	for _, value := range req.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	client := peer
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr := parseAddr(hops[i])
+
+		if !addr.IsValid() {
+			break
+		}
+
+		client = addr
+
+		if !r.IsTrusted(addr) {
+			break
+		}
+	}
+
+	return client
+}
+
+// Middleware resolve o IP uma vez por requisição para os demais handlers.
+func (r *Resolver) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextKey, r.ClientIP(c.Request))
+		c.Next()
+	}
+}
+
+// FromContext devolve o IP resolvido pelo Middleware. Sem ele, usa apenas o
+// endereço da conexão, sem confiar em cabeçalho algum.
+func FromContext(c *gin.Context) netip.Addr {
+	if value, ok := c.Get(contextKey); ok {
+		if addr, ok := value.(netip.Addr); ok {
+			return addr
+		}
+	}
+
+	return parseAddr(c.Request.RemoteAddr)
+}
+
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParsePrefixes aceita CIDRs ("10.0.0.0/8", "2400:cb00::/32") e IPs isolados.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+
+	for _, value := range values {
+		prefix, err := ParsePrefix(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes, nil
+}
+
+func ParsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("CIDR inválido %q: %v", value, err)
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("IP inválido %q: %v", value, err)
+	}
+
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// LoadPrefixesFile lê um CIDR por linha, no formato das listas publicadas pelo
+// Cloudflare (https://www.cloudflare.com/ips-v4 e ips-v6). Linhas vazias e
+// comentários iniciados por # são ignorados.
+func LoadPrefixesFile(path string) ([]netip.Prefix, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir lista de IPs %s: %v", path, err)
+	}
+
+	defer file.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		values = append(values, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("falha ao ler lista de IPs %s: %v", path, err)
+	}
+
+	prefixes, err := ParsePrefixes(values)
+
+	if err != nil {
+		return nil, fmt.Errorf("lista de IPs %s: %v", path, err)
+	}
+
+	return prefixes, nil
+}
+
+// parseAddr aceita "ip", "ip:porta" e "[ipv6]:porta". Endereços IPv4 mapeados
+// em IPv6 (::ffff:10.0.0.1) viram IPv4 para casar com os CIDRs configurados.
+func parseAddr(value string) netip.Addr {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return netip.Addr{}
+	}
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(value, "[]"))
+
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap().WithZone("")
+}
+
+// LoadTrustedProxies junta os proxies informados diretamente com os das listas
+// em arquivo.
+func LoadTrustedProxies(values []string, files []string) ([]netip.Prefix, error) {
+	prefixes, err := ParsePrefixes(values)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filePrefixes, err := LoadPrefixesFiles(files)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(prefixes, filePrefixes...), nil
+}
+
+// LoadPrefixesFiles junta as faixas de várias listas em arquivo.
+func LoadPrefixesFiles(paths []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, path := range paths {
+		filePrefixes, err := LoadPrefixesFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, filePrefixes...)
+	}
+
+	return prefixes, nil
+}
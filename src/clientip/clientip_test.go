@@ -0,0 +1,76 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cloudflare, err := ParsePrefixes([]string{"2400:cb00::/32", "173.245.48.0/20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := NewResolver(trusted, cloudflare)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"direct client", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"spoofed header from untrusted peer", "203.0.113.5:4000", map[string]string{"CF-Connecting-IP": "10.0.0.1"}, "203.0.113.5"},
+		{"cloudflare header from trusted peer", "[2400:cb00::1]:443", map[string]string{"CF-Connecting-IP": "2001:db8::7"}, "2001:db8::7"},
+		{"cloudflare header through a non-cloudflare proxy", "10.0.0.2:80", map[string]string{"CF-Connecting-IP": "10.0.0.1", "X-Forwarded-For": "203.0.113.5"}, "203.0.113.5"},
+		{"cloudflare header through a proxy without forwarded for", "192.0.2.10:80", map[string]string{"CF-Connecting-IP": "10.0.0.1"}, "192.0.2.10"},
+		{"real ip is ignored", "192.0.2.10:80", map[string]string{"X-Real-IP": "10.0.0.1"}, "192.0.2.10"},
+		{"cloudflare behind a trusted proxy", "10.0.0.2:80", map[string]string{"CF-Connecting-IP": "10.0.0.1", "X-Forwarded-For": "198.51.100.9, 173.245.48.1"}, "198.51.100.9"},
+		{"forwarded for skips trusted hops", "10.0.0.2:80", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.9, 10.0.0.3"}, "198.51.100.9"},
+		{"forwarded for only trusted hops", "10.0.0.2:80", map[string]string{"X-Forwarded-For": "10.0.0.4"}, "10.0.0.4"},
+		{"forwarded for with garbage", "10.0.0.2:80", map[string]string{"X-Forwarded-For": "nonsense"}, "10.0.0.2"},
+		{"ipv4 mapped peer", "[::ffff:10.0.0.2]:80", map[string]string{"X-Forwarded-For": "198.51.100.2"}, "198.51.100.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			if got := resolver.ClientIP(req).String(); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadPrefixesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ips.txt")
+	content := "# comentário\n173.245.48.0/20\n\n2606:4700::/32\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prefixes, err := LoadPrefixesFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(prefixes) != 2 || prefixes[1].String() != "2606:4700::/32" {
+		t.Errorf("unexpected prefixes: %v", prefixes)
+	}
+
+	if _, err := ParsePrefixes([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
@@ -1,18 +1,596 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"qr-code-boost/src/clientip"
+	"qr-code-boost/src/i18n"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
-func GetEnvVariable(key string) (string, error) {
-	value, ok := os.LookupEnv(key)
+// Config reúne toda a configuração da API. É carregada uma única vez na
+// inicialização e repassada para quem precisa dela.
+type Config struct {
+	Port             string        `yaml:"port"`
+	WebURL           string        `yaml:"webUrl"`
+	MigrateOnStartup bool          `yaml:"migrateOnStartup"`
+	QueryTimeout     time.Duration `yaml:"queryTimeout"`
+	DefaultLanguage  string        `yaml:"defaultLanguage"` // pt-BR ou en, sem Accept-Language compatível
+	Server           ServerConfig  `yaml:"server"`
+	Log              LogConfig     `yaml:"log"`
+	Tracing          TracingConfig `yaml:"tracing"`
+	AllowedCIDRs     []string      `yaml:"allowedCidrs"`
+	DeniedCIDRs      []string      `yaml:"deniedCidrs"`
+	// Só conexões vindas destes proxies têm os cabeçalhos de IP encaminhado
+	// considerados. Os arquivos seguem o formato das listas do Cloudflare.
+	TrustedProxies    []string `yaml:"trustedProxies"`
+	TrustedProxyFiles []string `yaml:"trustedProxyFiles"`
+	// Listas de faixas do Cloudflare: são proxies confiáveis e as únicas
+	// conexões de quem o CF-Connecting-IP é aceito.
+	CloudflareIPFiles []string            `yaml:"cloudflareIpFiles"`
+	NearScans         NearScansConfig     `yaml:"nearScans"`
+	ScanIngestion     ScanIngestionConfig `yaml:"scanIngestion"`
+	SlugCache         SlugCacheConfig     `yaml:"slugCache"`
+	RateLimit         RateLimitConfig     `yaml:"rateLimit"`
+	Mongo             MongoConfig         `yaml:"mongo"`
+	Postgres          PostgresConfig      `yaml:"postgres"`
+	Storage           StorageConfig       `yaml:"storage"`
+	JWT               JWTConfig           `yaml:"jwt"`
+}
+
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes"`
+	// Tempo para terminar as requisições em andamento após o SIGTERM. A fila de
+	// scans tem outro prazo igual depois disso.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn ou error
+	Format string `yaml:"format"` // json ou text
+}
+
+// TracingConfig escolhe para onde vão os spans do OpenTelemetry. Com
+// exporter "none" os spans não são gravados, mas o trace context recebido
+// continua sendo propagado.
+type TracingConfig struct {
+	Exporter    string `yaml:"exporter"` // none, otlp, stdout ou file
+	ServiceName string `yaml:"serviceName"`
+	// URL do coletor OTLP/HTTP, como http://localhost:4318. Vazia, vale a
+	// OTEL_EXPORTER_OTLP_ENDPOINT lida pelo próprio exporter.
+	OTLPEndpoint string `yaml:"otlpEndpoint"`
+	File         string `yaml:"file"` // Usado pelo exporter file, um span JSON por linha
+	// Fração dos traces iniciados aqui que são gravados. Requisições que já
+	// chegam com um trace seguem a decisão de quem chamou.
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+type NearScansConfig struct {
+	// Raio padrão, em metros, quando a requisição não informa maxDistance
+	DefaultMaxDistance int64 `yaml:"defaultMaxDistance"`
+}
+
+type ScanIngestionConfig struct {
+	QueueSize      int           `yaml:"queueSize"`
+	BatchSize      int           `yaml:"batchSize"`
+	FlushInterval  time.Duration `yaml:"flushInterval"`
+	EnqueueTimeout time.Duration `yaml:"enqueueTimeout"`
+}
+
+// SlugCacheConfig controla o cache de slugs usado no redirecionamento.
+// Capacity zero desliga o cache.
+type SlugCacheConfig struct {
+	Capacity int           `yaml:"capacity"`
+	TTL      time.Duration `yaml:"ttl"`
+	// Slugs inexistentes ficam menos tempo em cache para que um QR Code recém
+	// criado em outra réplica passe a funcionar logo.
+	NegativeTTL time.Duration `yaml:"negativeTtl"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Com RedisURL os limites são compartilhados entre as réplicas; sem ela
+	// cada instância conta em memória.
+	RedisURL string          `yaml:"redisUrl"`
+	Access   RateLimitPolicy `yaml:"access"`  // Rota pública /:slug, por IP
+	API      RateLimitPolicy `yaml:"api"`     // Rotas autenticadas, por API key
+	APIByIP  RateLimitPolicy `yaml:"apiByIp"` // Rotas autenticadas, por IP, antes da autenticação
+}
+
+type RateLimitPolicy struct {
+	Limit  int           `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+}
+
+type MongoConfig struct {
+	URL            string        `yaml:"url"`
+	Database       string        `yaml:"database"`
+	ConnectTimeout time.Duration `yaml:"connectTimeout"`
+}
+
+type PostgresConfig struct {
+	URL string `yaml:"url"`
+}
+
+type StorageConfig struct {
+	Driver    string   `yaml:"driver"` // "local" ou "s3"
+	LocalDir  string   `yaml:"localDir"`
+	PublicURL string   `yaml:"publicUrl"`
+	S3        S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint        string        `yaml:"endpoint"`
+	Bucket          string        `yaml:"bucket"`
+	AccessKeyId     string        `yaml:"accessKeyId"`
+	SecretAccessKey string        `yaml:"secretAccessKey"`
+	Region          string        `yaml:"region"`
+	UseSSL          bool          `yaml:"useSsl"`
+	PublicURL       string        `yaml:"publicUrl"`
+	SignedURLTTL    time.Duration `yaml:"signedUrlTtl"`
+}
+
+type JWTConfig struct {
+	JWKSURL  string `yaml:"jwksUrl"`
+	JWKSFile string `yaml:"jwksFile"`
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+}
+
+// ValidationError lista todos os problemas encontrados de uma vez, para que
+// não seja preciso reiniciar a API a cada variável corrigida.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "configuração inválida:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func Default() *Config {
+	return &Config{
+		Port:            "8080",
+		QueryTimeout:    5 * time.Second,
+		DefaultLanguage: "pt-BR",
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "qr-code-boost",
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
+		Server: ServerConfig{
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20, // 1 MB
+			ShutdownTimeout:   15 * time.Second,
+		},
+		AllowedCIDRs: []string{
+			"172.28.0.0/16", // Rede Docker
+			"127.0.0.0/8",   // Localhost
+			"10.0.0.0/8",    // Redes privadas
+			"::1/128",       // Localhost IPv6
+			"fc00::/7",      // Redes privadas IPv6
+		},
+		NearScans: NearScansConfig{
+			DefaultMaxDistance: 3000,
+		},
+		ScanIngestion: ScanIngestionConfig{
+			QueueSize:      10000,
+			BatchSize:      500,
+			FlushInterval:  time.Second,
+			EnqueueTimeout: 50 * time.Millisecond,
+		},
+		SlugCache: SlugCacheConfig{
+			Capacity:    10000,
+			TTL:         time.Minute,
+			NegativeTTL: 10 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Access:  RateLimitPolicy{Limit: 60, Period: time.Minute},
+			API:     RateLimitPolicy{Limit: 300, Period: time.Minute},
+			APIByIP: RateLimitPolicy{Limit: 600, Period: time.Minute},
+		},
+		Mongo: MongoConfig{
+			Database:       "qr-code-boost",
+			ConnectTimeout: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver:    "local",
+			LocalDir:  "./static/images",
+			PublicURL: "/images", // Servido pelo próprio router via router.Static
+			S3: S3Config{
+				UseSSL:       true,
+				SignedURLTTL: time.Hour,
+			},
+		},
+	}
+}
+
+// Load parte dos valores padrão, aplica o arquivo YAML de CONFIG_FILE (se
+// houver) e por fim as variáveis de ambiente, que incluem as do .env.
+func Load() (*Config, error) {
+	err := godotenv.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("falha ao ler o arquivo .env: %v", err)
+	}
+
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := cfg.applyEnv()
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("falha ao ler o arquivo de configuração %s: %v", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true) // Chaves com erro de digitação não passam despercebidas
+
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("arquivo de configuração %s inválido: %v", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) applyEnv() []string {
+	env := &envReader{}
+
+	env.string("PORT", &c.Port)
+	env.string("WEB_URL", &c.WebURL)
+	env.bool("MIGRATE_ON_STARTUP", &c.MigrateOnStartup)
+	env.duration("QUERY_TIMEOUT", &c.QueryTimeout)
+	env.string("DEFAULT_LANGUAGE", &c.DefaultLanguage)
+	env.string("LOG_LEVEL", &c.Log.Level)
+	env.string("LOG_FORMAT", &c.Log.Format)
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_SERVICE_NAME", &c.Tracing.ServiceName)
+	env.string("TRACING_OTLP_ENDPOINT", &c.Tracing.OTLPEndpoint)
+	env.string("TRACING_FILE", &c.Tracing.File)
+	env.float64("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.int("SERVER_MAX_HEADER_BYTES", &c.Server.MaxHeaderBytes)
+	env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.list("ALLOWED_CIDRS", &c.AllowedCIDRs)
+	env.list("DENIED_CIDRS", &c.DeniedCIDRs)
+	env.list("TRUSTED_PROXIES", &c.TrustedProxies)
+	env.list("TRUSTED_PROXY_FILES", &c.TrustedProxyFiles)
+	env.list("CLOUDFLARE_IP_FILES", &c.CloudflareIPFiles)
+	env.int64("NEAR_SCANS_DEFAULT_MAX_DISTANCE", &c.NearScans.DefaultMaxDistance)
+
+	env.int("SCAN_QUEUE_SIZE", &c.ScanIngestion.QueueSize)
+	env.int("SCAN_BATCH_SIZE", &c.ScanIngestion.BatchSize)
+	env.duration("SCAN_FLUSH_INTERVAL", &c.ScanIngestion.FlushInterval)
+	env.duration("SCAN_ENQUEUE_TIMEOUT", &c.ScanIngestion.EnqueueTimeout)
+
+	env.int("SLUG_CACHE_CAPACITY", &c.SlugCache.Capacity)
+	env.duration("SLUG_CACHE_TTL", &c.SlugCache.TTL)
+	env.duration("SLUG_CACHE_NEGATIVE_TTL", &c.SlugCache.NegativeTTL)
+
+	env.bool("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	env.string("REDIS_URL", &c.RateLimit.RedisURL)
+	env.int("RATE_LIMIT_ACCESS_LIMIT", &c.RateLimit.Access.Limit)
+	env.duration("RATE_LIMIT_ACCESS_PERIOD", &c.RateLimit.Access.Period)
+	env.int("RATE_LIMIT_API_LIMIT", &c.RateLimit.API.Limit)
+	env.duration("RATE_LIMIT_API_PERIOD", &c.RateLimit.API.Period)
+	env.int("RATE_LIMIT_API_IP_LIMIT", &c.RateLimit.APIByIP.Limit)
+	env.duration("RATE_LIMIT_API_IP_PERIOD", &c.RateLimit.APIByIP.Period)
+
+	env.string("MONGODB_URL", &c.Mongo.URL)
+	env.string("MONGODB_DATABASE", &c.Mongo.Database)
+	env.duration("MONGODB_CONNECT_TIMEOUT", &c.Mongo.ConnectTimeout)
+
+	env.string("DATABASE_URL", &c.Postgres.URL)
+
+	env.string("STORAGE_DRIVER", &c.Storage.Driver)
+	env.string("STORAGE_LOCAL_DIR", &c.Storage.LocalDir)
+	env.string("STORAGE_PUBLIC_URL", &c.Storage.PublicURL)
+	env.string("S3_ENDPOINT", &c.Storage.S3.Endpoint)
+	env.string("S3_BUCKET", &c.Storage.S3.Bucket)
+	env.string("S3_ACCESS_KEY_ID", &c.Storage.S3.AccessKeyId)
+	env.string("S3_SECRET_ACCESS_KEY", &c.Storage.S3.SecretAccessKey)
+	env.string("S3_REGION", &c.Storage.S3.Region)
+	env.bool("S3_USE_SSL", &c.Storage.S3.UseSSL)
+	env.string("S3_PUBLIC_URL", &c.Storage.S3.PublicURL)
+	env.duration("S3_SIGNED_URL_TTL", &c.Storage.S3.SignedURLTTL)
+
+	env.string("JWT_JWKS_URL", &c.JWT.JWKSURL)
+	env.string("JWT_JWKS_FILE", &c.JWT.JWKSFile)
+	env.string("JWT_ISSUER", &c.JWT.Issuer)
+	env.string("JWT_AUDIENCE", &c.JWT.Audience)
+
+	return env.problems
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT deve ser uma porta válida, recebido %q", c.Port))
+	}
+
+	if webURL, err := url.Parse(c.WebURL); c.WebURL == "" || err != nil || webURL.Scheme == "" || webURL.Host == "" {
+		problems = append(problems, fmt.Sprintf("WEB_URL deve ser uma URL absoluta, recebido %q", c.WebURL))
+	}
+
+	if c.QueryTimeout <= 0 {
+		problems = append(problems, "QUERY_TIMEOUT deve ser maior que zero")
+	}
+
+	if _, err := clientip.ParsePrefixes(c.AllowedCIDRs); err != nil {
+		problems = append(problems, fmt.Sprintf("ALLOWED_CIDRS: %v", err))
+	}
+
+	if _, err := clientip.ParsePrefixes(c.DeniedCIDRs); err != nil {
+		problems = append(problems, fmt.Sprintf("DENIED_CIDRS: %v", err))
+	}
+
+	if _, err := clientip.LoadTrustedProxies(c.TrustedProxies, c.TrustedProxyFiles); err != nil {
+		problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES: %v", err))
+	}
+
+	if _, err := clientip.LoadPrefixesFiles(c.CloudflareIPFiles); err != nil {
+		problems = append(problems, fmt.Sprintf("CLOUDFLARE_IP_FILES: %v", err))
+	}
+
+	if _, err := i18n.Parse(c.DefaultLanguage); err != nil {
+		problems = append(problems, fmt.Sprintf("DEFAULT_LANGUAGE inválido %q, use %s", c.DefaultLanguage, strings.Join(i18n.Supported(), " ou ")))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL inválido %q, use debug, info, warn ou error", c.Log.Level))
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT inválido %q, use json ou text", c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			problems = append(problems, "TRACING_FILE é obrigatório para o exporter file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER inválido %q, use none, otlp, stdout ou file", c.Tracing.Exporter))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "TRACING_SAMPLE_RATIO deve estar entre 0 e 1")
+	}
+
+	if c.Server.ReadTimeout <= 0 || c.Server.ReadHeaderTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		problems = append(problems, "SERVER_READ_TIMEOUT, SERVER_READ_HEADER_TIMEOUT, SERVER_WRITE_TIMEOUT e SERVER_IDLE_TIMEOUT devem ser maiores que zero")
+	}
+
+	if c.Server.MaxHeaderBytes <= 0 {
+		problems = append(problems, "SERVER_MAX_HEADER_BYTES deve ser maior que zero")
+	}
+
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT deve ser maior que zero")
+	}
+
+	if c.NearScans.DefaultMaxDistance <= 0 {
+		problems = append(problems, "NEAR_SCANS_DEFAULT_MAX_DISTANCE deve ser maior que zero")
+	}
+
+	if c.ScanIngestion.QueueSize <= 0 || c.ScanIngestion.BatchSize <= 0 {
+		problems = append(problems, "SCAN_QUEUE_SIZE e SCAN_BATCH_SIZE devem ser maiores que zero")
+	}
+
+	if c.ScanIngestion.FlushInterval <= 0 {
+		problems = append(problems, "SCAN_FLUSH_INTERVAL deve ser maior que zero")
+	}
+
+	if c.ScanIngestion.EnqueueTimeout < 0 {
+		problems = append(problems, "SCAN_ENQUEUE_TIMEOUT não pode ser negativo")
+	}
+
+	if c.SlugCache.Capacity < 0 {
+		problems = append(problems, "SLUG_CACHE_CAPACITY não pode ser negativo")
+	}
+
+	if c.SlugCache.Capacity > 0 && (c.SlugCache.TTL <= 0 || c.SlugCache.NegativeTTL <= 0) {
+		problems = append(problems, "SLUG_CACHE_TTL e SLUG_CACHE_NEGATIVE_TTL devem ser maiores que zero")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Access.Limit <= 0 || c.RateLimit.Access.Period <= 0 {
+			problems = append(problems, "RATE_LIMIT_ACCESS_LIMIT e RATE_LIMIT_ACCESS_PERIOD devem ser maiores que zero")
+		}
+		if c.RateLimit.API.Limit <= 0 || c.RateLimit.API.Period <= 0 {
+			problems = append(problems, "RATE_LIMIT_API_LIMIT e RATE_LIMIT_API_PERIOD devem ser maiores que zero")
+		}
+		if c.RateLimit.APIByIP.Limit <= 0 || c.RateLimit.APIByIP.Period <= 0 {
+			problems = append(problems, "RATE_LIMIT_API_IP_LIMIT e RATE_LIMIT_API_IP_PERIOD devem ser maiores que zero")
+		}
+	}
+
+	if c.Mongo.URL == "" {
+		problems = append(problems, "MONGODB_URL é obrigatório")
+	}
+
+	if c.Mongo.Database == "" {
+		problems = append(problems, "MONGODB_DATABASE não pode ser vazio")
+	}
+
+	if c.Mongo.ConnectTimeout <= 0 {
+		problems = append(problems, "MONGODB_CONNECT_TIMEOUT deve ser maior que zero")
+	}
+
+	if c.Postgres.URL == "" {
+		problems = append(problems, "DATABASE_URL é obrigatório")
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.LocalDir == "" {
+			problems = append(problems, "STORAGE_LOCAL_DIR não pode ser vazio")
+		}
+	case "s3":
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" {
+			problems = append(problems, "S3_ENDPOINT e S3_BUCKET são obrigatórios para o storage s3")
+		}
+		if c.Storage.S3.PublicURL == "" && c.Storage.S3.SignedURLTTL <= 0 {
+			problems = append(problems, "S3_SIGNED_URL_TTL deve ser maior que zero quando S3_PUBLIC_URL não é informado")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE_DRIVER deve ser \"local\" ou \"s3\", recebido %q", c.Storage.Driver))
+	}
+
+	if c.JWT.JWKSURL != "" && c.JWT.JWKSFile != "" {
+		problems = append(problems, "informe apenas um entre JWT_JWKS_URL e JWT_JWKS_FILE")
+	}
+
+	// Sem iss e aud qualquer token assinado pelo provedor, emitido para outra
+	// aplicação, seria aceito
+	if (c.JWT.JWKSURL != "" || c.JWT.JWKSFile != "") && (c.JWT.Issuer == "" || c.JWT.Audience == "") {
+		problems = append(problems, "JWT_ISSUER e JWT_AUDIENCE são obrigatórios quando o JWKS é configurado")
+	}
+
+	return problems
+}
+
+// envReader sobrescreve os valores apenas quando a variável está preenchida e acumula
+// os erros de conversão em vez de parar no primeiro.
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) string(key string, target *string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+func (r *envReader) bool(key string, target *bool) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s deve ser true ou false, recebido %q", key, value))
+		return
+	}
+
+	*target = parsed
+}
+
+func (r *envReader) int(key string, target *int) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s deve ser um número inteiro, recebido %q", key, value))
+		return
+	}
+
+	*target = parsed
+}
+
+func (r *envReader) int64(key string, target *int64) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s deve ser um número inteiro, recebido %q", key, value))
+		return
+	}
+
+	*target = parsed
+}
+
+func (r *envReader) float64(key string, target *float64) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s deve ser um número, recebido %q", key, value))
+		return
+	}
+
+	*target = parsed
+}
+
+func (r *envReader) duration(key string, target *time.Duration) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s deve ser uma duração como 5s ou 1m, recebido %q", key, value))
+		return
+	}
+
+	*target = parsed
+}
+
+func (r *envReader) list(key string, target *[]string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
 
-	if !ok {
-		fmt.Printf("Variável de ambiente %s não encontrada.\n", key)
-		return "", errors.New("environment variable not found")
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
 
-	return value, nil
+	*target = items
 }
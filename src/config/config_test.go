@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("WEB_URL", "https://qr.example.com")
+	t.Setenv("MONGODB_URL", "mongodb://localhost:27017")
+	t.Setenv("DATABASE_URL", "postgres://localhost/qr")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Port != "8080" || cfg.Mongo.Database != "qr-code-boost" || cfg.NearScans.DefaultMaxDistance != 3000 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadFileThenEnv(t *testing.T) {
+	setRequiredEnv(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "port: \"9090\"\nqueryTimeout: 2s\nmongo:\n  database: from-file\nnearScans:\n  defaultMaxDistance: 500\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("MONGODB_DATABASE", "from-env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Port != "9090" || cfg.QueryTimeout != 2*time.Second || cfg.NearScans.DefaultMaxDistance != 500 {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+	if cfg.Mongo.Database != "from-env" {
+		t.Errorf("env should override file, got %q", cfg.Mongo.Database)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("WEB_URL", "qr.example.com")
+	t.Setenv("MONGODB_URL", "")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("QUERY_TIMEOUT", "soon")
+	t.Setenv("ALLOWED_CIDRS", "10.0.0.0/8, not-a-cidr")
+	t.Setenv("STORAGE_DRIVER", "ftp")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("DEFAULT_LANGUAGE", "fr")
+
+	_, err := Load()
+
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	expected := []string{"WEB_URL", "MONGODB_URL", "DATABASE_URL", "QUERY_TIMEOUT", "not-a-cidr", "STORAGE_DRIVER", "LOG_LEVEL", "LOG_FORMAT", "DEFAULT_LANGUAGE"}
+	for _, name := range expected {
+		if !strings.Contains(validationErr.Error(), name) {
+			t.Errorf("report does not mention %s:\n%s", name, validationErr.Error())
+		}
+	}
+}
+
+func TestLoadRequiresIssuerAndAudienceWithJWKS(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_JWKS_URL", "https://id.example.com/.well-known/jwks.json")
+	t.Setenv("JWT_ISSUER", "https://id.example.com/")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "JWT_AUDIENCE") {
+		t.Fatalf("expected a JWT_AUDIENCE problem, got %v", err)
+	}
+
+	t.Setenv("JWT_AUDIENCE", "qr-code-boost")
+
+	if _, err := Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
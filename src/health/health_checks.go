@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	qrmongo "qr-code-boost/src/mongo"
+	"qr-code-boost/src/postgres"
+	"qr-code-boost/src/storage"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func MongoCheck(client *mongo.Client) Check {
+	return Check{
+		Name: "mongo",
+		Run: func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		},
+	}
+}
+
+func PostgresCheck(db *sql.DB) Check {
+	return Check{
+		Name: "postgres",
+		Run: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+// StorageCheck só confirma que o diretório ou o bucket existe, sem verificar
+// permissão de escrita: gravar a cada probe geraria escrita constante no
+// bucket e disputa entre as réplicas pela mesma chave.
+func StorageCheck(imageStorage storage.Storage) Check {
+	return Check{
+		Name: "storage",
+		Run: func(ctx context.Context) error {
+			return imageStorage.Ping(ctx)
+		},
+	}
+}
+
+// MigrationsCheck falha enquanto houver migrations pendentes em algum dos
+// bancos, por exemplo durante um deploy em que outra réplica ainda as aplica.
+func MigrationsCheck(mongoDatabase *mongo.Database, postgresClient *sql.DB) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			mongoPending, err := qrmongo.PendingMigrations(ctx, mongoDatabase)
+
+			if err != nil {
+				return fmt.Errorf("mongo: %v", err)
+			}
+
+			postgresPending, err := postgres.PendingMigrations(ctx, postgresClient)
+
+			if err != nil {
+				return fmt.Errorf("postgres: %v", err)
+			}
+
+			if len(mongoPending) > 0 || len(postgresPending) > 0 {
+				return fmt.Errorf("migrations pendentes: mongo %v, postgres %v", mongoPending, postgresPending)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatsProvider devolve um bloco do /admin/stats, como as estatísticas do
+// MongoDB ou da fila de scans.
+type StatsProvider func(ctx context.Context) (any, error)
+
+type HealthController struct {
+	Service      *HealthService
+	Stats        map[string]StatsProvider
+	StatsTimeout time.Duration
+}
+
+// @Summary      Liveness probe
+// @Description  Returns 200 while the process is running. Dependencies are not checked.
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object} health.Report
+// @Router       /healthz [get]
+func (h *HealthController) Healthz(c *gin.Context) {
+	c.IndentedJSON(200, Report{Status: StatusOK})
+}
+
+// @Summary      Readiness probe
+// @Description  Checks MongoDB, PostgreSQL, that the image storage directory or bucket exists, and pending migrations. Write access to the storage is not verified. Returns 503 when any check fails or the API is shutting down.
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object} health.Report
+// @Failure      503 {object} health.Report
+// @Router       /readyz [get]
+func (h *HealthController) Readyz(c *gin.Context) {
+	if h.Service.IsShuttingDown() {
+		c.IndentedJSON(503, Report{Status: StatusFail, Checks: map[string]CheckResult{
+			"shutdown": {Status: StatusFail, Error: "API em encerramento"},
+		}})
+		return
+	}
+
+	report := h.Service.Ready(c.Request.Context())
+
+	if report.Status != StatusOK {
+		slog.WarnContext(c.Request.Context(), "Readiness falhou", "checks", report.Checks)
+		c.IndentedJSON(503, report)
+		return
+	}
+
+	c.IndentedJSON(200, report)
+}
+
+// @Summary      Internal statistics
+// @Description  Database sizes, scan ingestion queue and slug cache counters. Admins only.
+// @Tags         Health
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/stats [get]
+func (h *HealthController) AdminStats(c *gin.Context) {
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.IsAdmin() {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "stats.admin_only"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.StatsTimeout)
+	defer cancel()
+
+	stats := make(gin.H, len(h.Stats))
+
+	// Um bloco com erro não impede os demais de serem exibidos
+	for name, provider := range h.Stats {
+		value, err := provider(ctx)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Erro ao coletar estatísticas", "provider", name, logging.Err(err))
+			stats[name] = gin.H{"error": err.Error()}
+			continue
+		}
+
+		stats[name] = value
+	}
+
+	c.IndentedJSON(200, stats)
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(controller *HealthController, principal *auth.Principal) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	HealthRouter(router, controller, nil, func(c *gin.Context) {
+		if principal != nil {
+			auth.SetPrincipal(c, principal)
+		}
+		c.Next()
+	})
+
+	return router
+}
+
+func get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	return recorder
+}
+
+func decodeReport(t *testing.T, recorder *httptest.ResponseRecorder) Report {
+	t.Helper()
+
+	var report Report
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body.String(), err)
+	}
+
+	return report
+}
+
+func passing(name string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error { return nil }}
+}
+
+func TestHealthzDoesNotRunChecks(t *testing.T) {
+	failing := Check{Name: "mongo", Run: func(ctx context.Context) error { return errors.New("down") }}
+	router := newTestRouter(&HealthController{Service: NewHealthService(time.Second, failing)}, nil)
+
+	if recorder := get(router, "/healthz"); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+}
+
+func TestReadyzReportsEachDependency(t *testing.T) {
+	failing := Check{Name: "postgres", Run: func(ctx context.Context) error { return errors.New("connection refused") }}
+	router := newTestRouter(&HealthController{Service: NewHealthService(time.Second, passing("mongo"), failing)}, nil)
+
+	recorder := get(router, "/readyz")
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", recorder.Code)
+	}
+
+	report := decodeReport(t, recorder)
+
+	if report.Checks["mongo"].Status != StatusOK {
+		t.Errorf("mongo = %+v, want ok", report.Checks["mongo"])
+	}
+
+	if got := report.Checks["postgres"]; got.Status != StatusFail || got.Error != "connection refused" {
+		t.Errorf("postgres = %+v, want fail with the error", got)
+	}
+}
+
+func TestReadyzTimesOutSlowChecks(t *testing.T) {
+	slow := Check{Name: "mongo", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	router := newTestRouter(&HealthController{Service: NewHealthService(20*time.Millisecond, slow)}, nil)
+
+	if recorder := get(router, "/readyz"); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", recorder.Code)
+	}
+}
+
+func TestReadyzFailsWhileShuttingDown(t *testing.T) {
+	service := NewHealthService(time.Second, passing("mongo"))
+	router := newTestRouter(&HealthController{Service: service}, nil)
+
+	if recorder := get(router, "/readyz"); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 before shutdown", recorder.Code)
+	}
+
+	service.SetShuttingDown()
+
+	if recorder := get(router, "/readyz"); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 during shutdown", recorder.Code)
+	}
+}
+
+func TestStorageCheckDoesNotWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	imageStorage, err := storage.NewLocalStorage(dir, "/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := StorageCheck(imageStorage).Run(t.Context()); err != nil {
+		t.Fatalf("storage check failed: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("storage check wrote %v", entries)
+	}
+
+	os.RemoveAll(dir)
+
+	if err := StorageCheck(imageStorage).Run(t.Context()); err == nil {
+		t.Error("storage check passed without the images directory")
+	}
+}
+
+func TestAdminStatsOnlyForAdmins(t *testing.T) {
+	controller := &HealthController{
+		Service:      NewHealthService(time.Second),
+		StatsTimeout: time.Second,
+		Stats: map[string]StatsProvider{
+			"slugCache": func(ctx context.Context) (any, error) { return gin.H{"hits": 3}, nil },
+			"mongo":     func(ctx context.Context) (any, error) { return nil, errors.New("unauthorized") },
+		},
+	}
+
+	if recorder := get(newTestRouter(controller, &auth.Principal{UserId: "user"}), "/admin/stats"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403 for non-admins", recorder.Code)
+	}
+
+	recorder := get(newTestRouter(controller, &auth.Principal{Scopes: []string{auth.ScopeAdmin}}), "/admin/stats")
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+
+	var stats map[string]map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	// O erro de um bloco não esconde os demais
+	if stats["slugCache"]["hits"] != float64(3) || stats["mongo"]["error"] != "unauthorized" {
+		t.Errorf("stats = %v", stats)
+	}
+}
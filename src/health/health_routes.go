@@ -0,0 +1,18 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Health Routes
+// O /healthz fica sempre aberto para o orquestrador. readyGuards protegem o
+// /readyz, que expõe erros das dependências; adminGuards, o grupo /admin.
+func HealthRouter(r *gin.Engine, healthController *HealthController, readyGuards []gin.HandlerFunc, adminGuards ...gin.HandlerFunc) {
+	r.GET("/healthz", healthController.Healthz)
+	r.GET("/readyz", append(readyGuards, healthController.Readyz)...)
+
+	adminRoutes := r.Group("/admin", adminGuards...)
+	{
+		adminRoutes.GET("/stats", healthController.AdminStats)
+	}
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check verifica uma dependência da API. Run deve respeitar o ctx, que expira
+// após o Timeout do HealthService.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type HealthService struct {
+	Checks  []Check
+	Timeout time.Duration
+
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(timeout time.Duration, checks ...Check) *HealthService {
+	return &HealthService{Checks: checks, Timeout: timeout}
+}
+
+// SetShuttingDown faz o /readyz falhar para o balanceador parar de enviar
+// tráfego enquanto as requisições em andamento terminam.
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *HealthService) IsShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// Ready executa todas as verificações em paralelo. Basta uma falhar para o
+// relatório ficar com status "fail".
+func (s *HealthService) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(s.Checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range s.Checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Run(ctx)
+
+			result := CheckResult{Status: StatusOK, Duration: time.Since(start).Round(time.Millisecond).String()}
+
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = result
+
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
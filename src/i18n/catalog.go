@@ -0,0 +1,90 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+// Os códigos são o contrato com os integradores: aparecem no campo code das
+// respostas de erro e não mudam quando o texto é revisado.
+var catalogs = map[language.Tag]map[string]string{
+	PortugueseBR: portugueseBR,
+	English:      english,
+}
+
+var portugueseBR = map[string]string{
+	"server.internal":         "Erro interno do servidor.",
+	"server.timeout":          "Tempo limite excedido ao processar a requisição.",
+	"server.canceled":         "Requisição cancelada pelo cliente.",
+	"server.internal_only":    "Acesso restrito à rede interna.",
+	"request.invalid_body":    "Corpo da requisição inválido.",
+	"request.invalid_param":   "Parâmetro %s inválido.",
+	"request.invalid_date":    "Parâmetro %s inválido, use AAAA-MM-DD.",
+	"request.invalid_page":    "Parâmetros de paginação inválidos.",
+	"request.empty_param":     "%s não pode ser vazio.",
+	"ratelimit.exceeded":      "Muitas requisições. Tente novamente em instantes.",
+	"idempotency.failed":      "Erro ao processar Idempotency-Key.",
+	"idempotency.key_reused":  "Idempotency-Key já utilizada com outro corpo de requisição.",
+	"idempotency.in_progress": "Requisição com esta Idempotency-Key ainda está em processamento.",
+	"auth.required":           "Autenticação necessária.",
+	"auth.token_missing":      "Bearer token não informado.",
+	"auth.token_invalid":      "Bearer token inválido.",
+	"auth.token_user_missing": "Usuário do token não encontrado.",
+	"auth.api_key_missing":    "API key não informada.",
+	"auth.api_key_invalid":    "API key inválida.",
+	"user.not_found":          "Usuário não encontrado.",
+	"user.email_taken":        "E-mail já cadastrado.",
+	"user.forbidden":          "Sem permissão para acessar este usuário.",
+	"user.admin_only_create":  "Apenas administradores podem criar usuários.",
+	"user.admin_only_list":    "Apenas administradores podem listar usuários.",
+	"workspace.forbidden":     "Sem permissão neste workspace.",
+	"workspace.last_owner":    "O workspace precisa manter ao menos um owner.",
+	"workspace.not_found":     "Workspace não encontrado.",
+	"qrcode.not_found":        "QR Code não encontrado.",
+	"qrcode.slug_taken":       "Slug já está em uso.",
+	"qrcode.user_deleted":     "Usuário removido não pode criar QR Codes.",
+	"qrcode.forbidden_list":   "Sem permissão para acessar os QR Codes deste usuário.",
+	"qrcode.forbidden_create": "Sem permissão para criar QR Codes para este usuário.",
+	"qrcode.forbidden_access": "Sem permissão para acessar este QR Code.",
+	"stats.invalid_range":     "O parâmetro from deve ser anterior ou igual a to.",
+	"stats.admin_only":        "Apenas administradores podem ver as estatísticas.",
+	"validation.invalid":      "%s é inválido",
+	"validation.type":         "%s deve ser do tipo %s",
+}
+
+var english = map[string]string{
+	"server.internal":         "Internal server error.",
+	"server.timeout":          "Timed out while processing the request.",
+	"server.canceled":         "Request canceled by the client.",
+	"server.internal_only":    "Access restricted to the internal network.",
+	"request.invalid_body":    "Invalid request body.",
+	"request.invalid_param":   "Invalid %s parameter.",
+	"request.invalid_date":    "Invalid %s parameter, use YYYY-MM-DD.",
+	"request.invalid_page":    "Invalid pagination parameters.",
+	"request.empty_param":     "%s must not be empty.",
+	"ratelimit.exceeded":      "Too many requests. Please try again shortly.",
+	"idempotency.failed":      "Failed to process the Idempotency-Key.",
+	"idempotency.key_reused":  "Idempotency-Key already used with a different request body.",
+	"idempotency.in_progress": "A request with this Idempotency-Key is still being processed.",
+	"auth.required":           "Authentication required.",
+	"auth.token_missing":      "Bearer token not provided.",
+	"auth.token_invalid":      "Invalid bearer token.",
+	"auth.token_user_missing": "Token user not found.",
+	"auth.api_key_missing":    "API key not provided.",
+	"auth.api_key_invalid":    "Invalid API key.",
+	"user.not_found":          "User not found.",
+	"user.email_taken":        "Email already registered.",
+	"user.forbidden":          "Not allowed to access this user.",
+	"user.admin_only_create":  "Only administrators can create users.",
+	"user.admin_only_list":    "Only administrators can list users.",
+	"workspace.forbidden":     "Not allowed in this workspace.",
+	"workspace.last_owner":    "The workspace must keep at least one owner.",
+	"workspace.not_found":     "Workspace not found.",
+	"qrcode.not_found":        "QR code not found.",
+	"qrcode.slug_taken":       "Slug is already in use.",
+	"qrcode.user_deleted":     "Deleted users cannot create QR codes.",
+	"qrcode.forbidden_list":   "Not allowed to access this user's QR codes.",
+	"qrcode.forbidden_create": "Not allowed to create QR codes for this user.",
+	"qrcode.forbidden_access": "Not allowed to access this QR code.",
+	"stats.invalid_range":     "The from parameter must be before or equal to to.",
+	"stats.admin_only":        "Only administrators can view statistics.",
+	"validation.invalid":      "%s is invalid",
+	"validation.type":         "%s must be of type %s",
+}
@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	PortugueseBR = language.BrazilianPortuguese
+	English      = language.English
+
+	// A ordem só importa para o matcher; o idioma padrão vem da configuração
+	supported = []language.Tag{PortugueseBR, English}
+	matcher   = language.NewMatcher(supported)
+
+	defaultLanguage atomic.Value
+)
+
+func init() {
+	defaultLanguage.Store(PortugueseBR)
+}
+
+// Supported lista os idiomas com catálogo, como aparecem na configuração.
+func Supported() []string {
+	names := make([]string, len(supported))
+	for i, tag := range supported {
+		names[i] = tag.String()
+	}
+	return names
+}
+
+// Parse aceita apenas idiomas com catálogo, escritos como em Supported.
+func Parse(name string) (language.Tag, error) {
+	for _, tag := range supported {
+		if tag.String() == name {
+			return tag, nil
+		}
+	}
+
+	return language.Und, fmt.Errorf("idioma sem catálogo %q", name)
+}
+
+// SetDefault define o idioma usado quando o cliente não envia Accept-Language
+// ou não aceita nenhum dos idiomas com catálogo. Também é o idioma dos logs.
+func SetDefault(tag language.Tag) {
+	defaultLanguage.Store(tag)
+}
+
+func Default() language.Tag {
+	return defaultLanguage.Load().(language.Tag)
+}
+
+// Match escolhe o idioma pelo cabeçalho Accept-Language, respeitando os pesos
+// q. Variantes regionais caem no idioma mais próximo (en-GB vira en).
+func Match(acceptLanguage string) language.Tag {
+	if acceptLanguage == "" {
+		return Default()
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+
+	if err != nil || len(tags) == 0 {
+		return Default()
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+
+	if confidence == language.No {
+		return Default()
+	}
+
+	return supported[index]
+}
+
+type languageKey struct{}
+
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, tag)
+}
+
+// FromContext devolve o idioma escolhido para a requisição, ou o padrão.
+func FromContext(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(languageKey{}).(language.Tag); ok {
+		return tag
+	}
+
+	return Default()
+}
+
+// Message traduz o código de uma mensagem do catálogo. Um código ausente no
+// idioma pedido usa o do idioma padrão e, em último caso, o próprio código.
+func Message(tag language.Tag, code string, args ...any) string {
+	format, ok := catalogs[tag][code]
+
+	if !ok {
+		format, ok = catalogs[Default()][code]
+	}
+
+	if !ok {
+		return code
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, args...)
+}
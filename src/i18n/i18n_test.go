@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		acceptLanguage string
+		want           language.Tag
+	}{
+		{"", PortugueseBR},
+		{"en", English},
+		{"en-GB,en;q=0.9", English},
+		{"pt", PortugueseBR},
+		{"pt-PT", PortugueseBR},
+		{"fr-FR,en;q=0.5", English},
+		{"de;q=0.9, pt-BR;q=0.2, en;q=0.8", English},
+		{"fr, de", PortugueseBR},
+		{"*", PortugueseBR},
+		{";;not a header", PortugueseBR},
+	}
+
+	for _, tc := range cases {
+		if got := Match(tc.acceptLanguage); got != tc.want {
+			t.Errorf("Match(%q) = %s, want %s", tc.acceptLanguage, got, tc.want)
+		}
+	}
+}
+
+func TestMatchUsesConfiguredDefault(t *testing.T) {
+	SetDefault(English)
+	defer SetDefault(PortugueseBR)
+
+	if got := Match("fr"); got != English {
+		t.Errorf("Match(fr) = %s, want the default en", got)
+	}
+}
+
+func TestCatalogsHaveTheSameCodes(t *testing.T) {
+	for tag, catalog := range catalogs {
+		for other, otherCatalog := range catalogs {
+			for code := range catalog {
+				if _, ok := otherCatalog[code]; !ok {
+					t.Errorf("%s is in %s but missing from %s", code, tag, other)
+				}
+			}
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if got := Message(English, "request.invalid_param", "maxDistance"); got != "Invalid maxDistance parameter." {
+		t.Errorf("message = %q", got)
+	}
+
+	if got := Message(PortugueseBR, "request.invalid_param", "maxDistance"); got != "Parâmetro maxDistance inválido." {
+		t.Errorf("message = %q", got)
+	}
+
+	if got := Message(English, "unknown.code"); got != "unknown.code" {
+		t.Errorf("unknown code = %q, want the code itself", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, name := range Supported() {
+		if _, err := Parse(name); err != nil {
+			t.Errorf("Parse(%q): %v", name, err)
+		}
+	}
+
+	if _, err := Parse("fr"); err == nil {
+		t.Error("Parse(fr) accepted a language without catalog")
+	}
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"qr-code-boost/src/config"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nomes dos campos usados em todos os logs, para que as buscas por um QR Code
+// ou usuário encontrem as linhas de qualquer pacote.
+const (
+	KeyRequestId   = "requestId"
+	KeySlug        = "slug"
+	KeyUserId      = "userId"
+	KeyQRCodeId    = "qrCodeId"
+	KeyWorkspaceId = "workspaceId"
+	KeyError       = "error"
+	KeyTraceId     = "traceId"
+	KeySpanId      = "spanId"
+)
+
+func Slug(slug string) slog.Attr {
+	return slog.String(KeySlug, slug)
+}
+
+func UserId(userId string) slog.Attr {
+	return slog.String(KeyUserId, userId)
+}
+
+func QRCodeId(id primitive.ObjectID) slog.Attr {
+	return slog.String(KeyQRCodeId, id.Hex())
+}
+
+func WorkspaceId(workspaceId string) slog.Attr {
+	return slog.String(KeyWorkspaceId, workspaceId)
+}
+
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// Setup cria o logger configurado e o torna o padrão do slog e do pacote log.
+func Setup(cfg config.LogConfig) *slog.Logger {
+	logger := New(os.Stdout, cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New aceita apenas formato e nível já validados por config.Load.
+func New(w io.Writer, cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level))
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+type requestIdKey struct{}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// contextHandler acrescenta o requestId e o trace do contexto aos logs feitos
+// com as variantes *Context do slog (slog.InfoContext, logger.ErrorContext...),
+// para ir de uma linha de log ao trace da requisição.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestIdFromContext(ctx); requestId != "" {
+		record.AddAttrs(slog.String(KeyRequestId, requestId))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String(KeyTraceId, spanContext.TraceID().String()),
+			slog.String(KeySpanId, spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,11 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Metrics Routes
+// guards devem restringir o acesso à rede interna, onde fica o Prometheus.
+func MetricsRouter(r *gin.Engine, guards ...gin.HandlerFunc) {
+	r.GET("/metrics", append(guards, gin.WrapH(Handler()))...)
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"database/sql"
+	"log/slog"
+	"qr-code-boost/src/apikey"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuthMiddleware resolve o usuário dono da chave enviada em X-API-Key e
+// o disponibiliza para os controllers via auth.GetPrincipal.
+func APIKeyAuthMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plainKey := c.GetHeader("X-API-Key")
+
+		if plainKey == "" {
+			apperror.Respond(c, apperror.New(apperror.Unauthorized, "auth.api_key_missing"))
+			return
+		}
+
+		apiKey, err := apikey.FindByKey(c.Request.Context(), plainKey, db)
+
+		if err != nil {
+			apperror.Respond(c, err)
+			return
+		}
+
+		if err := apikey.TouchLastUsed(c.Request.Context(), apiKey.Id, db); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Erro ao atualizar uso da API key", logging.Err(err))
+		}
+
+		auth.SetPrincipal(c, &auth.Principal{
+			UserId:   apiKey.UserId,
+			Scopes:   apiKey.Scopes,
+			Method:   "api_key",
+			APIKeyId: apiKey.Id,
+		})
+
+		c.Next()
+	}
+}
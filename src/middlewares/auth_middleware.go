@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"database/sql"
+	"qr-code-boost/src/auth"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMiddleware aceita tanto bearer tokens (quando há um verifier
+// configurado) quanto API keys em X-API-Key.
+func AuthMiddleware(db *sql.DB, verifier *auth.JWTVerifier) gin.HandlerFunc {
+	apiKeyAuth := APIKeyAuthMiddleware(db)
+
+	if verifier == nil {
+		return apiKeyAuth
+	}
+
+	jwtAuth := JWTAuthMiddleware(verifier, db)
+
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+			jwtAuth(c)
+			return
+		}
+
+		apiKeyAuth(c)
+	}
+}
@@ -0,0 +1,173 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type idempotencyRecord struct {
+	ID          string    `bson:"_id"`
+	RequestHash string    `bson:"requestHash"`
+	Status      int       `bson:"status"` // 0 enquanto a primeira requisição está em andamento
+	ContentType string    `bson:"contentType,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+}
+
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware guarda a primeira resposta de cada Idempotency-Key e a
+// repete para requisições iguais. A mesma chave com outro corpo recebe 422.
+// Os registros expiram pelo índice TTL criado pelas migrations do mongo.
+func IdempotencyMiddleware(db *mongo.Database) gin.HandlerFunc {
+	collection := db.Collection("idempotency_keys")
+
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// O workspace escolhido faz parte da requisição tanto quanto o corpo
+		hash := sha256.Sum256(append([]byte(c.GetHeader("X-Workspace-ID")+"\n"), body...))
+
+		// Chaves iguais de usuários diferentes não podem colidir
+		scope := "anonymous"
+		if principal, ok := auth.GetPrincipal(c); ok {
+			scope = principal.UserId
+		}
+
+		record := idempotencyRecord{
+			ID:          fmt.Sprintf("%s %s %s %s", scope, c.Request.Method, c.FullPath(), key),
+			RequestHash: hex.EncodeToString(hash[:]),
+			CreatedAt:   time.Now(),
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		_, err = collection.InsertOne(ctx, record)
+
+		if mongo.IsDuplicateKeyError(err) {
+			replayIdempotentResponse(c, collection, record)
+			return
+		}
+
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Erro ao registrar chave de idempotência", logging.Err(err))
+			apperror.Respond(c, apperror.New(apperror.Internal, "idempotency.failed"))
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Em um defer para rodar também quando o handler entra em panic: sem
+		// isso o registro ficaria "em processamento" até expirar
+		completed := false
+		defer func() {
+			saveIdempotentResponse(c, collection, record.ID, writer, completed)
+		}()
+
+		c.Next()
+
+		completed = true
+	}
+}
+
+// saveIdempotentResponse guarda a resposta para as próximas requisições com a
+// mesma chave. Falhas do servidor, panics, limites de requisição e
+// requisições abandonadas pelo cliente não são memorizados, assim o cliente
+// pode tentar de novo.
+func saveIdempotentResponse(c *gin.Context, collection *mongo.Collection, id string, writer *capturingWriter, completed bool) {
+	// A resposta é salva mesmo que o cliente já tenha desistido dela
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 5*time.Second)
+	defer cancel()
+
+	var err error
+
+	if !completed || !isReplayable(c, writer.Status()) {
+		_, err = collection.DeleteOne(ctx, bson.M{"_id": id})
+	} else {
+		_, err = collection.UpdateByID(ctx, id, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: writer.Status()},
+			{Key: "contentType", Value: writer.Header().Get("Content-Type")},
+			{Key: "body", Value: writer.body.Bytes()},
+		}}})
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Erro ao salvar resposta da chave de idempotência", logging.Err(err))
+	}
+}
+
+// isReplayable diz se a resposta é o resultado definitivo da operação. Um
+// 429 ou uma requisição cancelada não dizem nada sobre ela.
+func isReplayable(c *gin.Context, status int) bool {
+	if c.Request.Context().Err() != nil {
+		return false
+	}
+
+	return status < 500 && status != http.StatusTooManyRequests && status != apperror.StatusClientClosedRequest
+}
+
+func replayIdempotentResponse(c *gin.Context, collection *mongo.Collection, record idempotencyRecord) {
+	var stored idempotencyRecord
+
+	err := collection.FindOne(c.Request.Context(), bson.M{"_id": record.ID}).Decode(&stored)
+
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Erro ao buscar chave de idempotência", logging.Err(err))
+		apperror.Respond(c, apperror.New(apperror.Internal, "idempotency.failed"))
+		return
+	}
+
+	if stored.RequestHash != record.RequestHash {
+		apperror.Respond(c, apperror.New(apperror.Unprocessable, "idempotency.key_reused"))
+		return
+	}
+
+	if stored.Status == 0 {
+		apperror.Respond(c, apperror.New(apperror.Conflict, "idempotency.in_progress"))
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.Status, stored.ContentType, stored.Body)
+	c.Abort()
+}
@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"qr-code-boost/src/apperror"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const idempotencyBody = `{"slug":"launch"}`
+
+func newIdempotencyRouter(mt *mtest.T, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RecoveryMiddleware())
+	router.POST("/qr/", IdempotencyMiddleware(mt.DB), handler)
+
+	return router
+}
+
+func postWithKey(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("POST", "/qr/", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Idempotency-Key", "retry-1")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// storedRecord simula o documento já gravado por uma requisição anterior.
+func storedRecord(mt *mtest.T, body string, status int) bson.D {
+	hash := sha256.Sum256([]byte("\n" + body))
+
+	return mtest.CreateCursorResponse(0, mt.DB.Name()+".idempotency_keys", mtest.FirstBatch, bson.D{
+		{Key: "_id", Value: "anonymous POST /qr/ retry-1"},
+		{Key: "requestHash", Value: hex.EncodeToString(hash[:])},
+		{Key: "status", Value: status},
+		{Key: "contentType", Value: "application/json; charset=utf-8"},
+		{Key: "body", Value: []byte(`{"slug":"launch"}`)},
+	})
+}
+
+var duplicateKey = mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"})
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	created := func(c *gin.Context) {
+		c.IndentedJSON(201, gin.H{"slug": "launch"})
+	}
+
+	mt.Run("first request stores the response", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		recorder := postWithKey(newIdempotencyRouter(mt, created), idempotencyBody)
+
+		if recorder.Code != 201 {
+			t.Fatalf("status = %d, want 201", recorder.Code)
+		}
+
+		assertCommands(t, mt, "insert", "update")
+	})
+
+	mt.Run("repeated request is replayed", func(mt *mtest.T) {
+		mt.AddMockResponses(duplicateKey, storedRecord(mt, idempotencyBody, 201))
+
+		handlerCalled := false
+		recorder := postWithKey(newIdempotencyRouter(mt, func(c *gin.Context) { handlerCalled = true }), idempotencyBody)
+
+		if recorder.Code != 201 || recorder.Body.String() != `{"slug":"launch"}` || recorder.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("replay = %d %s, headers %v", recorder.Code, recorder.Body, recorder.Header())
+		}
+
+		if handlerCalled {
+			t.Error("handler ran for a replayed request")
+		}
+	})
+
+	mt.Run("same key with another body", func(mt *mtest.T) {
+		mt.AddMockResponses(duplicateKey, storedRecord(mt, `{"slug":"other"}`, 201))
+
+		recorder := postWithKey(newIdempotencyRouter(mt, created), idempotencyBody)
+
+		if recorder.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status = %d, want 422", recorder.Code)
+		}
+	})
+
+	mt.Run("first request still in progress", func(mt *mtest.T) {
+		mt.AddMockResponses(duplicateKey, storedRecord(mt, idempotencyBody, 0))
+
+		recorder := postWithKey(newIdempotencyRouter(mt, created), idempotencyBody)
+
+		if recorder.Code != http.StatusConflict {
+			t.Errorf("status = %d, want 409", recorder.Code)
+		}
+	})
+
+	mt.Run("server errors are forgotten", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		recorder := postWithKey(newIdempotencyRouter(mt, func(c *gin.Context) {
+			c.Status(http.StatusServiceUnavailable)
+		}), idempotencyBody)
+
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503", recorder.Code)
+		}
+
+		assertCommands(t, mt, "insert", "delete")
+	})
+
+	for _, status := range []int{http.StatusTooManyRequests, apperror.StatusClientClosedRequest} {
+		mt.Run(fmt.Sprintf("status %d is forgotten", status), func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+			recorder := postWithKey(newIdempotencyRouter(mt, func(c *gin.Context) {
+				c.Status(status)
+			}), idempotencyBody)
+
+			if recorder.Code != status {
+				t.Fatalf("status = %d, want %d", recorder.Code, status)
+			}
+
+			assertCommands(t, mt, "insert", "delete")
+		})
+	}
+
+	mt.Run("requests abandoned by the client are forgotten", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		// O handler termina normalmente, mas o cliente já desconectou
+		router := newIdempotencyRouter(mt, func(c *gin.Context) {
+			cancel()
+			created(c)
+		})
+
+		request := httptest.NewRequestWithContext(ctx, "POST", "/qr/", strings.NewReader(idempotencyBody))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Idempotency-Key", "retry-1")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		assertCommands(t, mt, "insert", "delete")
+	})
+
+	mt.Run("panics are forgotten", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		recorder := postWithKey(newIdempotencyRouter(mt, func(c *gin.Context) {
+			panic("boom")
+		}), idempotencyBody)
+
+		if recorder.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want 500", recorder.Code)
+		}
+
+		assertCommands(t, mt, "insert", "delete")
+	})
+}
+
+func assertCommands(t *testing.T, mt *mtest.T, names ...string) {
+	t.Helper()
+
+	events := mt.GetAllStartedEvents()
+
+	if len(events) != len(names) {
+		t.Fatalf("%d commands sent, want %v", len(events), names)
+	}
+
+	for i, event := range events {
+		if event.CommandName != names[i] {
+			t.Errorf("command %d = %s, want %s", i, event.CommandName, names[i])
+		}
+	}
+}
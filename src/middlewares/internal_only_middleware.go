@@ -1,38 +1,25 @@
 package middlewares
 
 import (
-	"net"
+	"net/netip"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/clientip"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InternalOnlyMiddleware() gin.HandlerFunc {
+// InternalOnlyMiddleware libera apenas IPs da allowlist que não estejam na
+// denylist; a denylist tem precedência. O IP vem do resolver, que só aceita
+// cabeçalhos encaminhados por proxies confiáveis.
+func InternalOnlyMiddleware(resolver *clientip.Resolver, allowlist []netip.Prefix, denylist []netip.Prefix) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Obter IP real através do Cloudflare
-		realIP := c.GetHeader("CF-Connecting-IP")
-		if realIP == "" {
-			realIP = c.GetHeader("X-Real-IP")
-		}
-		if realIP == "" {
-			realIP = c.ClientIP()
-		}
-
-		ip := net.ParseIP(realIP)
-
-		allowedCIDRs := []string{
-			"172.28.0.0/16", // Rede Docker
-			"127.0.0.0/8",   // Localhost
-			"10.0.0.0/8",    // Redes privadas
-		}
+		ip := resolver.ClientIP(c.Request)
 
-		for _, cidr := range allowedCIDRs {
-			_, subnet, _ := net.ParseCIDR(cidr)
-			if subnet.Contains(ip) {
-				c.Next()
-				return
-			}
+		if !ip.IsValid() || clientip.Contains(denylist, ip) || !clientip.Contains(allowlist, ip) {
+			apperror.Respond(c, apperror.New(apperror.Forbidden, "server.internal_only"))
+			return
 		}
 
-		c.AbortWithStatusJSON(403, gin.H{"error": "forbidden"})
+		c.Next()
 	}
 }
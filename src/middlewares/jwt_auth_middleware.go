@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"database/sql"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/postgres"
+	"qr-code-boost/src/user"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWTAuthMiddleware valida o bearer token emitido pelo provedor de identidade
+// e resolve o claim "sub" para o usuário do Postgres.
+func JWTAuthMiddleware(verifier *auth.JWTVerifier, db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawToken, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		if !ok || rawToken == "" {
+			apperror.Respond(c, apperror.New(apperror.Unauthorized, "auth.token_missing"))
+			return
+		}
+
+		claims, err := verifier.Verify(c.Request.Context(), rawToken)
+
+		if err != nil {
+			slog.InfoContext(c.Request.Context(), "Token inválido", logging.Err(err))
+			apperror.Respond(c, err)
+			return
+		}
+
+		// Um sub que não é UUID nunca corresponde a um usuário
+		if !postgres.IsUUID(claims.Subject) {
+			slog.InfoContext(c.Request.Context(), "Token com sub inválido", "sub", claims.Subject)
+			apperror.Respond(c, apperror.New(apperror.Unauthorized, "auth.token_user_missing"))
+			return
+		}
+
+		foundUser, err := user.FindById(c.Request.Context(), claims.Subject, db)
+
+		if err != nil {
+			apperror.Respond(c, err)
+			return
+		}
+
+		if foundUser == nil || foundUser.IsDeleted() {
+			apperror.Respond(c, apperror.New(apperror.Unauthorized, "auth.token_user_missing"))
+			return
+		}
+
+		auth.SetPrincipal(c, &auth.Principal{
+			UserId: claims.Subject,
+			Scopes: claims.AllScopes(),
+			Method: "jwt",
+		})
+
+		c.Next()
+	}
+}
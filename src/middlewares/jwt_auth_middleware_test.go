@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/postgres/postgrestest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const jwtUserId = "7f1c1b2e-8c5e-4a8a-9d0a-3f7c1e9b2a10"
+
+func newJWTRouter(t *testing.T, subject string) (*gin.Engine, *postgrestest.Fake, string) {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := &auth.JWTVerifier{
+		Keys:     auth.NewStaticKeySet(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &private.PublicKey, KeyID: "key-1", Algorithm: string(jose.ES256)}}}),
+		Issuer:   "https://id.example.com/",
+		Audience: "qr-code-boost",
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: private}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   verifier.Issuer,
+		Audience: jwt.Audience{verifier.Audience},
+		Subject:  subject,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, fake := postgrestest.New(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", JWTAuthMiddleware(verifier, db), func(c *gin.Context) {
+		principal, _ := auth.GetPrincipal(c)
+		c.String(http.StatusOK, principal.UserId)
+	})
+
+	return router, fake, token
+}
+
+func getWithToken(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("GET", "/me", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestJWTAuthMiddlewareResolvesUser(t *testing.T) {
+	router, fake, token := newJWTRouter(t, jwtUserId)
+
+	now := time.Now()
+	fake.Expect("FROM").Rows(
+		[]string{"id", "name", "email", "created_at", "updated_at", "deleted_at"},
+		[]any{jwtUserId, "Ana", "ana@example.com", now, now, nil},
+	)
+
+	recorder := getWithToken(router, token)
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != jwtUserId {
+		t.Errorf("response = %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestJWTAuthMiddlewareRejectsNonUUIDSubject(t *testing.T) {
+	// Sem expectativas: o sub inválido não pode chegar ao Postgres
+	router, _, token := newJWTRouter(t, "user-1")
+
+	if recorder := getWithToken(router, token); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", recorder.Code)
+	}
+}
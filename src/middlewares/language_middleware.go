@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"qr-code-boost/src/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LanguageMiddleware escolhe o idioma das mensagens de erro pelo
+// Accept-Language e o guarda no contexto da requisição, onde o
+// apperror.Write o lê. Sem idioma compatível vale o DEFAULT_LANGUAGE.
+func LanguageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := i18n.Match(c.GetHeader("Accept-Language"))
+
+		// Só as respostas de erro são traduzidas, mas qualquer rota pode responder uma
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), tag))
+
+		c.Next()
+	}
+}
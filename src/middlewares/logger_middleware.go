@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"log/slog"
+	"qr-code-boost/src/clientip"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggerMiddleware substitui o logger do gin por uma linha estruturada por
+// requisição. Deve vir depois do RequestIdMiddleware para levar o requestId.
+func LoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("clientIp", clientip.FromContext(c).String()),
+			slog.String("userAgent", c.Request.UserAgent()),
+		}
+
+		if errors := c.Errors.ByType(gin.ErrorTypePrivate).String(); errors != "" {
+			attrs = append(attrs, slog.String("errors", errors))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "requisição", attrs...)
+	}
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"qr-code-boost/src/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware mede a duração de cada requisição. A rota é o padrão
+// registrado no gin (/:slug, /qr/near/:slug), não o caminho acessado, para que
+// cada slug não vire uma série nova. Deve ser registrado antes do
+// RecoveryMiddleware, para que os panics entrem na métrica como o 500 que ele
+// responde.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
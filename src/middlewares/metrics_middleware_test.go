@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"qr-code-boost/src/metrics"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsMiddlewareLabelsByRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/:slug", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/promo", "/launch", "/a/b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// Cada slug acessado não pode virar uma série nova
+	if count := testutil.CollectAndCount(metrics.HTTPRequestDuration, "qrboost_http_request_duration_seconds"); count != 2 {
+		t.Errorf("series = %d, want 2 (/:slug and unmatched)", count)
+	}
+
+	expected := []struct{ route, status string }{
+		{"/:slug", "200"},
+		{"unmatched", "404"},
+	}
+
+	for _, e := range expected {
+		if _, err := metrics.HTTPRequestDuration.GetMetricWithLabelValues("GET", e.route, e.status); err != nil {
+			t.Errorf("missing series for %s %s: %v", e.route, e.status, err)
+		}
+	}
+}
+
+func TestMetricsMiddlewareRecordsPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(MetricsMiddleware(), RecoveryMiddleware())
+	router.GET("/qr/stats/:slug", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/qr/stats/promo", nil))
+
+	observer, err := metrics.HTTPRequestDuration.GetMetricWithLabelValues("GET", "/qr/stats/:slug", "500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var metric dto.Metric
+	observer.(prometheus.Metric).Write(&metric)
+
+	if metric.GetHistogram().GetSampleCount() != 1 {
+		t.Errorf("panicking request not recorded as a 500")
+	}
+}
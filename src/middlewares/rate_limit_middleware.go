@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/clientip"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/ratelimit"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitKeyFunc identifica de quem é o bucket consumido pela requisição.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+func RateLimitByClientIP(c *gin.Context) string {
+	return "ip:" + clientip.FromContext(c).String()
+}
+
+// RateLimitByPrincipal separa os limites por API key (ou usuário, no JWT).
+// Deve rodar depois da autenticação; sem principal, usa o IP.
+func RateLimitByPrincipal(c *gin.Context) string {
+	principal, ok := auth.GetPrincipal(c)
+
+	switch {
+	case ok && principal.APIKeyId != "":
+		return "key:" + principal.APIKeyId
+	case ok && principal.UserId != "":
+		return "user:" + principal.UserId
+	default:
+		return RateLimitByClientIP(c)
+	}
+}
+
+// RateLimitMiddleware aplica a policy com os cabeçalhos RateLimit-* do draft
+// da IETF e Retry-After no 429. Se o store falhar a requisição segue, para que
+// uma queda do Redis não derrube a API.
+func RateLimitMiddleware(store ratelimit.Store, policy ratelimit.Policy, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Period.Seconds()))
+
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), policy.Name+":"+keyFunc(c), policy, time.Now())
+
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Erro ao consultar o rate limit, liberando a requisição", "policy", policy.Name, logging.Err(err))
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Policy", policyHeader)
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			apperror.Respond(c, apperror.New(apperror.TooManyRequests, "ratelimit.exceeded"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"qr-code-boost/src/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy := ratelimit.Policy{Name: "access", Limit: 1, Period: time.Minute}
+
+	router := gin.New()
+	router.GET("/:slug", RateLimitMiddleware(ratelimit.NewMemoryStore(), policy, RateLimitByClientIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/promo", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := request("203.0.113.5:1000")
+	if first.Code != http.StatusOK || first.Header().Get("RateLimit-Remaining") != "0" || first.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Fatalf("unexpected first response: %d %v", first.Code, first.Header())
+	}
+
+	second := request("203.0.113.5:1001")
+	if second.Code != http.StatusTooManyRequests || second.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected 429 with Retry-After, got %d %v", second.Code, second.Header())
+	}
+
+	if other := request("198.51.100.7:1000"); other.Code != http.StatusOK {
+		t.Fatalf("other client should not be limited, got %d", other.Code)
+	}
+}
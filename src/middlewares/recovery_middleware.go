@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/logging"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware substitui o gin.Recovery: um panic vira um 500 em JSON,
+// como os demais erros, e é registrado com o stack trace. Erros deixados em
+// c.Errors por um handler que não respondeu também são respondidos aqui.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+
+			if recovered == nil {
+				return
+			}
+
+			// Usado pelo net/http para abortar a resposta de propósito
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			err := fmt.Errorf("panic: %v", recovered)
+
+			slog.ErrorContext(c.Request.Context(), "Panic ao atender a requisição", logging.Err(err), "stack", string(debug.Stack()))
+
+			if c.Writer.Written() {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+
+			apperror.Respond(c, err)
+		}()
+
+		c.Next()
+
+		if !c.Writer.Written() && len(c.Errors) > 0 {
+			apperror.Write(c, c.Errors.Last().Err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"qr-code-boost/src/apperror"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RecoveryMiddleware())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	router.GET("/unhandled", func(c *gin.Context) {
+		c.Error(errors.New("falha sem resposta"))
+	})
+	router.GET("/not-found", func(c *gin.Context) {
+		apperror.Respond(c, apperror.New(apperror.NotFound, "QR Code não encontrado."))
+	})
+	router.GET("/timeout", func(c *gin.Context) {
+		apperror.Respond(c, context.DeadlineExceeded)
+	})
+
+	cases := []struct {
+		path    string
+		status  int
+		message string
+	}{
+		{"/panic", 500, "Erro interno do servidor."},
+		{"/unhandled", 500, "Erro interno do servidor."},
+		{"/not-found", 404, "QR Code não encontrado."},
+		{"/timeout", 504, "Tempo limite excedido ao processar a requisição."},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest("GET", tc.path, nil))
+
+			if recorder.Code != tc.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.status)
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != apperror.ProblemContentType {
+				t.Errorf("content type = %q, want %q", contentType, apperror.ProblemContentType)
+			}
+
+			var problem apperror.Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("body is not JSON: %v\n%s", err, recorder.Body)
+			}
+
+			if problem.Detail != tc.message || problem.Status != tc.status || problem.Instance != tc.path {
+				t.Errorf("problem = %+v, want detail %q and status %d", problem, tc.message, tc.status)
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddlewareRepanicsAbortHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RecoveryMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("recovered = %v, want http.ErrAbortHandler", recovered)
+		}
+	}()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
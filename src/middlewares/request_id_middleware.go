@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"qr-code-boost/src/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+// RequestIdMiddleware reaproveita o X-Request-ID enviado pelo cliente ou pelo
+// proxy, ou gera um novo, e o devolve na resposta. O ID vai para o contexto da
+// requisição para aparecer nos logs feitos com slog.*Context.
+func RequestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIdHeader)
+
+		if !validRequestId(requestId) {
+			requestId = newRequestId()
+		}
+
+		c.Header(RequestIdHeader, requestId)
+		c.Request = c.Request.WithContext(logging.WithRequestId(c.Request.Context(), requestId))
+
+		c.Next()
+	}
+}
+
+// validRequestId limita tamanho e caracteres, já que o valor vem do cliente e
+// é repetido nos logs e na resposta.
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > 128 {
+		return false
+	}
+
+	for _, char := range requestId {
+		isAlphanumeric := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+
+		if !isAlphanumeric && char != '-' && char != '_' && char != '.' && char != ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestId() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
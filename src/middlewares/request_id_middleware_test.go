@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/logging"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var fromContext string
+
+	router := gin.New()
+	router.Use(RequestIdMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		fromContext = logging.RequestIdFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	cases := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{"valid id is reused", "edge-7f3a:42", true},
+		{"missing id is generated", "", false},
+		{"unsafe characters", "abc\ndef", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/", nil)
+			if tc.incoming != "" {
+				request.Header.Set(RequestIdHeader, tc.incoming)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			got := recorder.Header().Get(RequestIdHeader)
+
+			if got == "" || got != fromContext {
+				t.Fatalf("header = %q, context = %q", got, fromContext)
+			}
+
+			if tc.reused != (got == tc.incoming) {
+				t.Errorf("request id = %q for incoming %q, reused = %v", got, tc.incoming, tc.reused)
+			}
+		})
+	}
+}
+
+func TestLoggerMiddlewareIncludesRequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buffer bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buffer, config.LogConfig{Level: "info", Format: "json"}))
+	defer slog.SetDefault(previous)
+
+	router := gin.New()
+	router.Use(RequestIdMiddleware(), LoggerMiddleware())
+	router.GET("/qr/:slug", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	request := httptest.NewRequest("GET", "/qr/promo", nil)
+	request.Header.Set(RequestIdHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	var line map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+		t.Fatalf("log is not a single JSON line: %v\n%s", err, buffer.String())
+	}
+
+	expected := map[string]any{
+		"level":     "ERROR",
+		"requestId": "req-1",
+		"route":     "/qr/:slug",
+		"path":      "/qr/promo",
+		"status":    float64(500),
+	}
+
+	for key, want := range expected {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %v", key, line[key], want)
+		}
+	}
+}
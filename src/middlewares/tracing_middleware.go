@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware abre o span de cada requisição, continuando o trace do
+// cabeçalho traceparent quando ele é enviado. As consultas ao MongoDB e ao
+// PostgreSQL feitas com o contexto da requisição viram spans filhos deste.
+func TracingMiddleware() gin.HandlerFunc {
+	tracer := tracing.Tracer()
+
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// Como nas métricas, o nome usa o padrão da rota e não o slug acessado
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		if requestId := logging.RequestIdFromContext(ctx); requestId != "" {
+			span.SetAttributes(attribute.String(logging.KeyRequestId, requestId))
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		for _, err := range c.Errors.ByType(gin.ErrorTypePrivate) {
+			span.RecordError(err.Err)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const migrationsCollection = "_migrations"
+
+// Um lock mais velho que isso é considerado abandonado por uma réplica que caiu.
+const migrationLockTimeout = 10 * time.Minute
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+type MigrationStatus struct {
+	Version   int        `json:"version" bson:"_id"`
+	Name      string     `json:"name" bson:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty" bson:"appliedAt"`
+}
+
+// Migrations devem apenas ser adicionadas ao final; as já aplicadas são
+// identificadas pela versão na collection _migrations.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_qrcodes_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("qrcodes"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "slug", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+				{
+					Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+				},
+				{
+					Keys:    bson.D{{Key: "workspaceId", Value: 1}},
+					Options: options.Index().SetSparse(true),
+				},
+				{
+					Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: nil,
+				},
+				{
+					Keys: bson.D{{Key: "userId", Value: 1}, {Key: "idempotencyKey", Value: 1}},
+					Options: options.Index().
+						SetUnique(true).
+						SetPartialFilterExpression(bson.D{{Key: "idempotencyKey", Value: bson.D{{Key: "$exists", Value: true}}}}),
+				},
+			})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create_scans_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("scans"), []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+				},
+				{
+					Keys: bson.D{{Key: "qrCodeId", Value: 1}, {Key: "scanedAt", Value: -1}},
+				},
+			})
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_idempotency_keys_ttl",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("idempotency_keys"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "createdAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60), // Respostas ficam disponíveis para replay por 24h
+				},
+			})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_collection_validators",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			err := applyValidator(ctx, db, "qrcodes", bson.M{
+				"bsonType": "object",
+				"required": []string{"slug", "link", "location", "userId"},
+				"properties": bson.M{
+					"slug":           bson.M{"bsonType": "string", "minLength": 2, "maxLength": 20},
+					"link":           bson.M{"bsonType": "string"},
+					"location":       locationSchema,
+					"userId":         bson.M{"bsonType": "string"},
+					"workspaceId":    bson.M{"bsonType": "string"},
+					"imageKey":       bson.M{"bsonType": "string"},
+					"idempotencyKey": bson.M{"bsonType": "string"},
+					"createdAt":      bson.M{"bsonType": "date"},
+					"updatedAt":      bson.M{"bsonType": "date"},
+				},
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return applyValidator(ctx, db, "scans", scansSchema())
+		},
+	},
+	{
+		Version: 5,
+		Name:    "backfill_qrcodes_image_key",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// QR Codes anteriores ao storage plugável salvavam a imagem como <id>.png
+			filter := bson.D{{Key: "imageKey", Value: bson.D{{Key: "$exists", Value: false}}}}
+			pipeline := mongo.Pipeline{
+				{{Key: "$set", Value: bson.D{{Key: "imageKey", Value: bson.D{
+					{Key: "$concat", Value: bson.A{bson.D{{Key: "$toString", Value: "$_id"}}, ".png"}},
+				}}}}},
+			}
+
+			result, err := db.Collection("qrcodes").UpdateMany(ctx, filter, pipeline)
+
+			if err != nil {
+				return err
+			}
+
+			slog.Info("imageKey preenchido nos QR Codes", "modified", result.ModifiedCount)
+			return nil
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_scans_is_bot_to_validator",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return applyValidator(ctx, db, "scans", scansSchema(scanIsBotProperty))
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create_visitor_ids",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// O sal de um dia precisa existir só enquanto o dia não termina em
+			// todos os fusos; depois disso o ID de visitante fica irreversível.
+			err := createIndexes(ctx, db.Collection("visitor_salts"), []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "createdAt", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(48 * 60 * 60),
+				},
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return applyValidator(ctx, db, "scans", scansSchema(scanIsBotProperty, scanVisitorIdProperty))
+		},
+	},
+	{
+		Version: 8,
+		Name:    "drop_qrcodes_idempotency_key_index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// As tentativas repetidas de criação passaram a ser tratadas só pelo
+			// IdempotencyMiddleware, na collection idempotency_keys
+			_, err := db.Collection("qrcodes").Indexes().DropOne(ctx, "userId_1_idempotencyKey_1")
+
+			var commandErr mongo.CommandError
+			if errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound" {
+				return nil
+			}
+
+			return err
+		},
+	},
+}
+
+var locationSchema = bson.M{
+	"bsonType": "object",
+	"required": []string{"type", "coordinates"},
+	"properties": bson.M{
+		"type":        bson.M{"enum": []string{"Point"}},
+		"coordinates": bson.M{"bsonType": "array", "minItems": 2, "maxItems": 2},
+	},
+}
+
+// Campos que as migrations foram acrescentando ao validator de scans.
+var (
+	scanIsBotProperty     = bson.M{"isBot": bson.M{"bsonType": "bool"}}
+	scanVisitorIdProperty = bson.M{"visitorId": bson.M{"bsonType": "string"}}
+)
+
+// scansSchema monta o validator de scans a partir dos campos originais mais
+// os informados, para que cada migration só declare o que acrescentou.
+func scansSchema(extra ...bson.M) bson.M {
+	properties := bson.M{
+		"qrCodeId": bson.M{"bsonType": "objectId"},
+		"location": locationSchema,
+		"scanedAt": bson.M{"bsonType": "date"},
+	}
+
+	for _, fields := range extra {
+		for name, schema := range fields {
+			properties[name] = schema
+		}
+	}
+
+	return bson.M{
+		"bsonType":   "object",
+		"required":   []string{"qrCodeId", "location", "scanedAt"},
+		"properties": properties,
+	}
+}
+
+// Migrate aplica as migrations pendentes do banco informado.
+func Migrate(db *mongo.Database) ([]Migration, error) {
+	ctx := context.Background()
+
+	var applied []Migration
+
+	err := withMigrationLock(ctx, db, func() error {
+		status, err := loadMigrationStatus(ctx, db)
+
+		if err != nil {
+			return err
+		}
+
+		for i, migration := range Migrations {
+			if status[i].AppliedAt != nil {
+				continue
+			}
+
+			slog.Info("Aplicando migration do mongo", "version", migration.Version, "name", migration.Name)
+
+			if err := migration.Up(ctx, db); err != nil {
+				return fmt.Errorf("falha na migration mongo %03d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			_, err := db.Collection(migrationsCollection).InsertOne(ctx, bson.M{
+				"_id":       migration.Version,
+				"name":      migration.Name,
+				"appliedAt": time.Now(),
+			})
+
+			if err != nil {
+				return err
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+func GetMigrationStatus(db *mongo.Database) ([]MigrationStatus, error) {
+	return loadMigrationStatus(context.Background(), db)
+}
+
+// PendingMigrations lista as versões ainda não aplicadas.
+func PendingMigrations(ctx context.Context, db *mongo.Database) ([]int, error) {
+	status, err := loadMigrationStatus(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []int
+
+	for _, item := range status {
+		if item.AppliedAt == nil {
+			pending = append(pending, item.Version)
+		}
+	}
+
+	return pending, nil
+}
+
+func loadMigrationStatus(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$type", Value: "number"}}}}
+
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var appliedList []MigrationStatus
+
+	if err := cursor.All(ctx, &appliedList); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]MigrationStatus, len(appliedList))
+	for _, item := range appliedList {
+		applied[item.Version] = item
+	}
+
+	status := make([]MigrationStatus, 0, len(Migrations))
+
+	for _, migration := range Migrations {
+		item := MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+		if stored, ok := applied[migration.Version]; ok {
+			if stored.Name != migration.Name {
+				return nil, fmt.Errorf("migration mongo %03d foi aplicada como %q, mas o código a chama de %q", migration.Version, stored.Name, migration.Name)
+			}
+			item.AppliedAt = stored.AppliedAt
+		}
+
+		status = append(status, item)
+	}
+
+	return status, nil
+}
+
+// withMigrationLock usa um documento com _id fixo como lock entre réplicas.
+func withMigrationLock(ctx context.Context, db *mongo.Database, run func() error) error {
+	collection := db.Collection(migrationsCollection)
+	lockFilter := bson.D{{Key: "_id", Value: "lock"}}
+
+	for {
+		_, err := collection.InsertOne(ctx, bson.M{"_id": "lock", "lockedAt": time.Now()})
+
+		if err == nil {
+			break
+		}
+
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("falha ao obter lock de migrations: %v", err)
+		}
+
+		staleFilter := bson.D{
+			{Key: "_id", Value: "lock"},
+			{Key: "lockedAt", Value: bson.D{{Key: "$lt", Value: time.Now().Add(-migrationLockTimeout)}}},
+		}
+
+		result, err := collection.DeleteOne(ctx, staleFilter)
+
+		if err != nil {
+			return err
+		}
+
+		if result.DeletedCount == 0 {
+			slog.Info("Aguardando outra réplica terminar as migrations do mongo")
+			time.Sleep(2 * time.Second)
+		}
+	}
+
+	defer collection.DeleteOne(context.Background(), lockFilter)
+
+	return run()
+}
+
+func createIndexes(ctx context.Context, collection *mongo.Collection, indexes []mongo.IndexModel) error {
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// applyValidator usa collMod em collections existentes e cria as que ainda
+// não existem. O nível "moderate" não bloqueia updates em documentos antigos
+// que já estavam fora do schema.
+func applyValidator(ctx context.Context, db *mongo.Database, collection string, schema bson.M) error {
+	validator := bson.M{"$jsonSchema": schema}
+
+	err := db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Name == "NamespaceNotFound" {
+		return db.CreateCollection(ctx, collection, options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate"))
+	}
+
+	return err
+}
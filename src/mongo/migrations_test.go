@@ -0,0 +1,174 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var duplicateKey = mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"})
+
+// commandNames lista os comandos enviados ao servidor, na ordem.
+func commandNames(mt *mtest.T) []string {
+	var names []string
+
+	for _, event := range mt.GetAllStartedEvents() {
+		names = append(names, event.CommandName)
+	}
+
+	return names
+}
+
+func TestWithMigrationLock(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("free lock is taken and released", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		ran := false
+		err := withMigrationLock(t.Context(), mt.DB, func() error {
+			ran = true
+			return nil
+		})
+
+		if err != nil || !ran {
+			t.Fatalf("err = %v, ran = %v", err, ran)
+		}
+
+		if names := commandNames(mt); len(names) != 2 || names[0] != "insert" || names[1] != "delete" {
+			t.Fatalf("commands = %v, want insert then delete", names)
+		}
+
+		events := mt.GetAllStartedEvents()
+		lock := events[0].Command.Lookup("documents").Array().Index(0).Value().Document()
+		if lock.Lookup("_id").StringValue() != "lock" {
+			t.Errorf("lock document = %s", lock)
+		}
+
+		release := events[1].Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		if release.Lookup("_id").StringValue() != "lock" {
+			t.Errorf("release filter = %s", release)
+		}
+	})
+
+	mt.Run("stale lock is removed and taken over", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			duplicateKey,
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		if err := withMigrationLock(t.Context(), mt.DB, func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"insert", "delete", "insert", "delete"}
+		names := commandNames(mt)
+
+		if len(names) != len(want) {
+			t.Fatalf("commands = %v, want %v", names, want)
+		}
+
+		for i := range want {
+			if names[i] != want[i] {
+				t.Fatalf("commands = %v, want %v", names, want)
+			}
+		}
+
+		// Só o lock mais velho que o timeout pode ser apagado
+		stale := mt.GetAllStartedEvents()[1].Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		if _, err := stale.LookupErr("lockedAt", "$lt"); err != nil {
+			t.Errorf("stale filter = %s, want a lockedAt bound", stale)
+		}
+	})
+
+	mt.Run("error acquiring the lock skips the migrations", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized"}))
+
+		ran := false
+		err := withMigrationLock(t.Context(), mt.DB, func() error {
+			ran = true
+			return nil
+		})
+
+		if err == nil || ran {
+			t.Errorf("err = %v, ran = %v", err, ran)
+		}
+	})
+
+	mt.Run("lock is released when a migration fails", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		failure := errors.New("migration failed")
+		err := withMigrationLock(t.Context(), mt.DB, func() error { return failure })
+
+		if !errors.Is(err, failure) {
+			t.Errorf("err = %v, want %v", err, failure)
+		}
+
+		if names := commandNames(mt); len(names) != 2 || names[1] != "delete" {
+			t.Errorf("commands = %v, want the lock to be released", names)
+		}
+	})
+}
+
+func TestBackfillImageKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("only documents without imageKey are updated", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		backfill := Migrations[4]
+		if backfill.Name != "backfill_qrcodes_image_key" {
+			t.Fatalf("Migrations[4] = %s", backfill.Name)
+		}
+
+		if err := backfill.Up(t.Context(), mt.DB); err != nil {
+			t.Fatal(err)
+		}
+
+		event := mt.GetStartedEvent()
+		if event.CommandName != "update" || event.Command.Lookup("update").StringValue() != "qrcodes" {
+			t.Fatalf("command = %s", event.Command)
+		}
+
+		update := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+
+		if exists, err := update.LookupErr("q", "imageKey", "$exists"); err != nil || exists.Boolean() {
+			t.Errorf("filter = %s, want imageKey $exists false", update.Lookup("q"))
+		}
+
+		if !update.Lookup("multi").Boolean() {
+			t.Error("backfill must update every matching document")
+		}
+
+		// O pipeline deriva a chave do _id, no formato antigo <id>.png
+		concat, err := update.Lookup("u").Array().Index(0).Value().Document().LookupErr("$set", "imageKey", "$concat")
+		if err != nil {
+			t.Fatalf("update = %s", update.Lookup("u"))
+		}
+
+		parts := concat.Array()
+		if parts.Index(0).Value().Document().Lookup("$toString").StringValue() != "$_id" || parts.Index(1).Value().StringValue() != ".png" {
+			t.Errorf("$concat = %s", parts)
+		}
+	})
+}
+
+func TestScansSchemaAddsFields(t *testing.T) {
+	latest := scansSchema(scanIsBotProperty, scanVisitorIdProperty)["properties"].(bson.M)
+
+	for _, field := range []string{"qrCodeId", "location", "scanedAt", "isBot", "visitorId"} {
+		if _, ok := latest[field]; !ok {
+			t.Errorf("latest scans schema is missing %s", field)
+		}
+	}
+
+	// Cada chamada monta um schema novo, sem herdar campos das anteriores
+	if original := scansSchema()["properties"].(bson.M); len(original) != 3 {
+		t.Errorf("original scans schema has %d properties, want 3", len(original))
+	}
+}
@@ -7,11 +7,13 @@ import (
 )
 
 type QRCode struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Slug      string             `bson:"slug"`
-	Link      string             `bson:"link"`
-	Location  Location           `bson:"location"`
-	UserId    string             `bson:"userId"`
-	CreatedAt time.Time          `bson:"createdAt,omitempty"`
-	UpdatedAt time.Time          `bson:"updatedAt,omitempty"`
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Slug        string             `bson:"slug"`
+	Link        string             `bson:"link"`
+	Location    Location           `bson:"location"`
+	UserId      string             `bson:"userId"`
+	WorkspaceId string             `bson:"workspaceId,omitempty"`
+	ImageKey    string             `bson:"imageKey,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt,omitempty"`
+	UpdatedAt   time.Time          `bson:"updatedAt,omitempty"`
 }
@@ -11,4 +11,7 @@ type Scan struct {
 	QRCodeId primitive.ObjectID `bson:"qrCodeId"`
 	Location Location           `bson:"location"`
 	ScanedAt time.Time          `bson:"scanedAt"`
+	IsBot    bool               `bson:"isBot"` // Pré-visualizações e crawlers, fora das estatísticas padrão
+	// Hash diário de IP + User-Agent, ver scan.VisitorHasher
+	VisitorId string `bson:"visitorId,omitempty"`
 }
@@ -3,11 +3,12 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"qr-code-boost/src/config"
-	"reflect"
-	"time"
+	"qr-code-boost/src/logging"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -24,57 +25,29 @@ type DBStats struct {
 	DatabaseDataSize      float64 `json:"databaseDataSize" bson:"dataSize"`
 	DatabaseStorageSize   float64 `json:"databaseStorageSize" bson:"storageSize"`
 	CollectionName        string  `json:"collectionName" bson:"-"`
-	CollectionDataSize    int32   `json:"collectionDataSize" bson:"size"`
-	CollectionStorageSize int32   `json:"collectionStorageSize" bson:"storageSize"`
-	DocumentCount         int32   `json:"documentCount" bson:"count"`
+	CollectionDataSize    int64   `json:"collectionDataSize" bson:"size"`
+	CollectionStorageSize int64   `json:"collectionStorageSize" bson:"storageSize"`
+	DocumentCount         int64   `json:"documentCount" bson:"count"`
 }
 
-func CreateIndexes(client *mongo.Client) {
-	qrCodesCollection := client.Database("qr-code-boost").Collection("qrcodes")
-
-	qrCodeIndexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "slug", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
-			Options: nil,
-		},
-	}
-
-	_, errQRCodes := qrCodesCollection.Indexes().CreateMany(context.Background(), qrCodeIndexes)
-
-	if errQRCodes != nil {
-		fmt.Printf("Erro ao criar índices para 'qrcodes': %v\n", errQRCodes)
-	} else {
-		fmt.Println("Índices da coleção 'qrcodes' verificados/criados.")
-	}
-
-	scansCollection := client.Database("qr-code-boost").Collection("scans")
-
-	scanLocationIndex := mongo.IndexModel{
-		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
-	}
-
-	_, errScans := scansCollection.Indexes().CreateOne(context.Background(), scanLocationIndex)
-	if errScans != nil {
-		fmt.Printf("Erro ao criar índice para 'scans': %v\n", errScans)
-	} else {
-		fmt.Println("Índice da coleção 'scans' verificado/criado.")
-	}
+// commandMonitor junta as métricas e os spans dos comandos, já que o driver
+// aceita apenas um monitor por client.
+var commandMonitor = &event.CommandMonitor{
+	Started: startCommandSpan,
+	Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+		observeCommand(e.CommandFinishedEvent, false)
+		endCommandSpan(e.CommandFinishedEvent, "")
+	},
+	Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+		observeCommand(e.CommandFinishedEvent, true)
+		endCommandSpan(e.CommandFinishedEvent, e.Failure)
+	},
 }
 
-func ConnectMongoDB() (*mongo.Client, error) {
-	databaseURL, err := config.GetEnvVariable("MONGODB_URL")
-
-	if err != nil {
-		return nil, err
-	}
+func ConnectMongoDB(cfg config.MongoConfig) (*mongo.Client, error) {
+	clientOptions := options.Client().ApplyURI(cfg.URL).SetMonitor(commandMonitor)
 
-	clientOptions := options.Client().ApplyURI(databaseURL)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -87,13 +60,11 @@ func ConnectMongoDB() (*mongo.Client, error) {
 		return nil, fmt.Errorf("falha ao verificar conexão: %v", err)
 	}
 
-	fmt.Println("Conexão com MongoDB estabelecida!")
+	slog.Info("Conexão com MongoDB estabelecida")
 	return client, nil
 }
 
-func GetDBStats(client *mongo.Client) (DBStats, error) {
-	ctx := context.Background()
-
+func GetDBStats(ctx context.Context, db *mongo.Database) (DBStats, error) {
 	var result DBStats
 	var dbStats bson.M
 	var collStats bson.M
@@ -101,39 +72,41 @@ func GetDBStats(client *mongo.Client) (DBStats, error) {
 	dbCommand := bson.D{{Key: "dbStats", Value: 1}, {Key: "scale", Value: 1024}}
 	collCommand := bson.D{{Key: "collStats", Value: "qrcodes"}, {Key: "scale", Value: 1024}}
 
-	errDBCommand := client.Database("qr-code-boost").RunCommand(ctx, dbCommand).Decode(&dbStats)
-	errCollCommand := client.Database("qr-code-boost").RunCommand(ctx, collCommand).Decode(&collStats)
+	errDBCommand := db.RunCommand(ctx, dbCommand).Decode(&dbStats)
+	errCollCommand := db.RunCommand(ctx, collCommand).Decode(&collStats)
 
 	if errDBCommand != nil {
-		fmt.Printf("Erro ao pegar estatísticas do banco Mongo DB: %v", errDBCommand)
+		slog.ErrorContext(ctx, "Erro ao executar dbStats", logging.Err(errDBCommand))
 		return DBStats{}, errDBCommand
 	}
 	if errCollCommand != nil {
-		fmt.Printf("Erro ao pegar estatísticas do banco Mongo DB: %v", errCollCommand)
+		slog.ErrorContext(ctx, "Erro ao executar collStats", logging.Err(errCollCommand))
 		return DBStats{}, errCollCommand
 	}
 
-	fmt.Println("Type size", reflect.TypeOf(collStats["size"]))
-	fmt.Println(collStats["size"])
+	result.DatabaseDataSize = number(dbStats["dataSize"])
+	result.DatabaseStorageSize = number(dbStats["storageSize"])
+	result.CollectionDataSize = int64(number(collStats["size"]))
+	result.CollectionStorageSize = int64(number(collStats["storageSize"]))
+	result.DocumentCount = int64(number(collStats["count"]))
 
-	if dataSize, ok := dbStats["dataSize"].(float64); ok {
-		result.DatabaseDataSize = dataSize
-	}
-	if storageSize, ok := dbStats["storageSize"].(float64); ok {
-		result.DatabaseStorageSize = storageSize
-	}
-	if size, ok := collStats["size"].(int32); ok {
-		result.CollectionDataSize = size
-	}
-	if storageSize, ok := collStats["storageSize"].(int32); ok {
-		result.CollectionStorageSize = storageSize
-	}
-	if count, ok := collStats["count"].(int32); ok {
-		result.DocumentCount = count
-	}
-
-	result.DatabaseName = "qr-code-boost"
+	result.DatabaseName = db.Name()
 	result.CollectionName = "qrcodes"
 
 	return result, nil
 }
+
+// number converte os valores numéricos do dbStats/collStats, que o MongoDB
+// devolve como int32, int64 ou double conforme o tamanho.
+func number(value any) float64 {
+	switch v := value.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
+	}
+}
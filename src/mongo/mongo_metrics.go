@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	commandDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "mongo",
+		Name:      "command_duration_seconds",
+		Help:      "Duração dos comandos enviados ao MongoDB (find, insert, aggregate...).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command"})
+
+	commandErrors = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "mongo",
+		Name:      "command_errors_total",
+		Help:      "Comandos do MongoDB que falharam.",
+	}, []string{"command"})
+)
+
+// observeCommand mede todos os comandos do client, inclusive os das
+// migrations e do change stream (cujo getMore fica aberto esperando eventos).
+func observeCommand(e event.CommandFinishedEvent, failed bool) {
+	commandDuration.WithLabelValues(e.CommandName).Observe(e.Duration.Seconds())
+
+	if failed {
+		commandErrors.WithLabelValues(e.CommandName).Inc()
+	}
+}
+
+// DBStatsCollector expõe o resultado de GetDBStats como gauges, consultando o
+// banco a cada coleta do Prometheus.
+type DBStatsCollector struct {
+	db      *mongo.Database
+	timeout time.Duration
+
+	databaseDataSize      *prometheus.Desc
+	databaseStorageSize   *prometheus.Desc
+	collectionDataSize    *prometheus.Desc
+	collectionStorageSize *prometheus.Desc
+	collectionDocuments   *prometheus.Desc
+	scrapeErrors          prometheus.Counter
+}
+
+func NewDBStatsCollector(db *mongo.Database, timeout time.Duration) *DBStatsCollector {
+	name := func(suffix string) string {
+		return prometheus.BuildFQName(metrics.Namespace, "mongo", suffix)
+	}
+
+	return &DBStatsCollector{
+		db:      db,
+		timeout: timeout,
+
+		databaseDataSize:      prometheus.NewDesc(name("database_data_size_bytes"), "Tamanho dos dados do banco.", []string{"database"}, nil),
+		databaseStorageSize:   prometheus.NewDesc(name("database_storage_size_bytes"), "Espaço em disco alocado para o banco.", []string{"database"}, nil),
+		collectionDataSize:    prometheus.NewDesc(name("collection_data_size_bytes"), "Tamanho dos dados da collection.", []string{"collection"}, nil),
+		collectionStorageSize: prometheus.NewDesc(name("collection_storage_size_bytes"), "Espaço em disco alocado para a collection.", []string{"collection"}, nil),
+		collectionDocuments:   prometheus.NewDesc(name("collection_documents"), "Quantidade de documentos da collection.", []string{"collection"}, nil),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "mongo",
+			Name:      "dbstats_errors_total",
+			Help:      "Coletas de dbStats/collStats que falharam.",
+		}),
+	}
+}
+
+func (c *DBStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.databaseDataSize
+	ch <- c.databaseStorageSize
+	ch <- c.collectionDataSize
+	ch <- c.collectionStorageSize
+	ch <- c.collectionDocuments
+	c.scrapeErrors.Describe(ch)
+}
+
+func (c *DBStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := GetDBStats(ctx, c.db)
+
+	if err != nil {
+		slog.Error("Erro ao coletar métricas do MongoDB", logging.Err(err))
+		c.scrapeErrors.Inc()
+	} else {
+		// GetDBStats usa escala de 1024, ou seja, KB
+		ch <- prometheus.MustNewConstMetric(c.databaseDataSize, prometheus.GaugeValue, stats.DatabaseDataSize*1024, stats.DatabaseName)
+		ch <- prometheus.MustNewConstMetric(c.databaseStorageSize, prometheus.GaugeValue, stats.DatabaseStorageSize*1024, stats.DatabaseName)
+		ch <- prometheus.MustNewConstMetric(c.collectionDataSize, prometheus.GaugeValue, float64(stats.CollectionDataSize)*1024, stats.CollectionName)
+		ch <- prometheus.MustNewConstMetric(c.collectionStorageSize, prometheus.GaugeValue, float64(stats.CollectionStorageSize)*1024, stats.CollectionName)
+		ch <- prometheus.MustNewConstMetric(c.collectionDocuments, prometheus.GaugeValue, float64(stats.DocumentCount), stats.CollectionName)
+	}
+
+	c.scrapeErrors.Collect(ch)
+}
@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"qr-code-boost/src/tracing"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// O Started e o Succeeded/Failed de um comando chegam em chamadas separadas
+// do monitor, então o span aberto fica guardado pela conexão e pelo ID do
+// comando até o fim.
+type commandSpanKey struct {
+	connectionId string
+	requestId    int64
+}
+
+var commandSpans sync.Map
+
+// startCommandSpan só abre spans para comandos feitos dentro de um trace
+// amostrado. Os lotes de scans, o change stream e as migrations não têm uma
+// requisição por trás e ficariam como traces soltos.
+func startCommandSpan(ctx context.Context, e *event.CommandStartedEvent) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+
+	name := e.CommandName
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemNameMongoDB,
+		semconv.DBNamespace(e.DatabaseName),
+		semconv.DBOperationName(e.CommandName),
+	}
+
+	// Em find, insert, aggregate... o valor do comando é a collection
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		name += " " + collection
+		attributes = append(attributes, semconv.DBCollectionName(collection))
+	}
+
+	_, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+
+	commandSpans.Store(commandSpanKey{e.ConnectionID, e.RequestID}, span)
+}
+
+func endCommandSpan(e event.CommandFinishedEvent, failure string) {
+	value, ok := commandSpans.LoadAndDelete(commandSpanKey{e.ConnectionID, e.RequestID})
+
+	if !ok {
+		return
+	}
+
+	var err error
+	if failure != "" {
+		err = errors.New(failure)
+	}
+
+	tracing.End(value.(trace.Span), err)
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCommandMonitorTracesCommandsWithinTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	command, _ := bson.Marshal(bson.D{{Key: "find", Value: "qrcodes"}})
+
+	run := func(ctx context.Context, requestId int64, failure string) {
+		finished := event.CommandFinishedEvent{CommandName: "find", ConnectionID: "conn-1", RequestID: requestId}
+
+		commandMonitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      command,
+			CommandName:  "find",
+			DatabaseName: "qr-code-boost",
+			ConnectionID: "conn-1",
+			RequestID:    requestId,
+		})
+
+		if failure != "" {
+			commandMonitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished, Failure: failure})
+		} else {
+			commandMonitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished})
+		}
+	}
+
+	// Sem trace, como no change stream, o comando só é medido
+	run(context.Background(), 1, "")
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	run(ctx, 2, "")
+	run(ctx, 3, "connection reset")
+	parent.End()
+
+	var commands []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "find qrcodes" {
+			commands = append(commands, span)
+		}
+	}
+
+	if len(commands) != 2 {
+		t.Fatalf("command spans = %d, want 2 (only those within the request)", len(commands))
+	}
+	if commands[0].Status().Code != codes.Unset || commands[1].Status().Code != codes.Error {
+		t.Errorf("statuses = %v, %v; want ok then error", commands[0].Status(), commands[1].Status())
+	}
+
+	remaining := 0
+	commandSpans.Range(func(any, any) bool { remaining++; return true })
+	if remaining != 0 {
+		t.Errorf("%d spans left open", remaining)
+	}
+}
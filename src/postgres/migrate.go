@@ -0,0 +1,366 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockId é a chave do advisory lock que garante que apenas uma
+// réplica aplique migrations por vez.
+const migrationLockId = 7_265_301
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// LoadMigrations lê os arquivos NNNN_nome.up.sql / NNNN_nome.down.sql embutidos.
+func LoadMigrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles)
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		filename := entry.Name()
+
+		base, direction, ok := strings.Cut(strings.TrimSuffix(filename, ".sql"), ".")
+		versionStr, name, hasName := strings.Cut(base, "_")
+
+		if !ok || !hasName || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("nome de migration inválido: %s", filename)
+		}
+
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("versão de migration inválida: %s", filename)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("migrations", filename))
+
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+			checksum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d sem arquivo up", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp aplica todas as migrations pendentes, cada uma em sua transação.
+func MigrateUp(db *sql.DB) ([]Migration, error) {
+	var applied []Migration
+
+	err := withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		migrations, appliedVersions, err := loadAndVerify(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+
+			slog.Info("Aplicando migration do postgres", "version", migration.Version, "name", migration.Name)
+
+			err := runInTx(ctx, conn, migration.Up, `
+				INSERT INTO schema_migrations (version, name, checksum)
+				VALUES ($1, $2, $3)
+			`, migration.Version, migration.Name, migration.Checksum)
+
+			if err != nil {
+				return fmt.Errorf("falha na migration %04d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown desfaz as últimas `steps` migrations aplicadas.
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		migrations, appliedVersions, err := loadAndVerify(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %04d_%s não possui arquivo down", migration.Version, migration.Name)
+			}
+
+			slog.Info("Revertendo migration do postgres", "version", migration.Version, "name", migration.Name)
+
+			err := runInTx(ctx, conn, migration.Down, `
+				DELETE FROM schema_migrations WHERE version = $1
+			`, migration.Version)
+
+			if err != nil {
+				return fmt.Errorf("falha ao reverter %04d_%s: %v", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	var status []MigrationStatus
+
+	err := withMigrationLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		migrations, appliedVersions, err := loadAndVerify(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			item := MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+			if appliedAt, ok := appliedVersions[migration.Version]; ok {
+				item.AppliedAt = &appliedAt
+			}
+
+			status = append(status, item)
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+// PendingMigrations lista as versões ainda não aplicadas sem obter o lock de
+// migrations, para ser barato o bastante para o /readyz.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]int64, error) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]bool{}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table: nada aplicado ainda
+		err = nil
+		rows = nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rows != nil {
+		defer rows.Close()
+
+		for rows.Next() {
+			var version int64
+
+			if err := rows.Scan(&version); err != nil {
+				return nil, err
+			}
+
+			applied[version] = true
+		}
+
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var pending []int64
+
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration.Version)
+		}
+	}
+
+	return pending, nil
+}
+
+func withMigrationLock(db *sql.DB, run func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	// O advisory lock é por sessão, então tudo roda na mesma conexão
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockId); err != nil {
+		return fmt.Errorf("falha ao obter lock de migrations: %v", err)
+	}
+
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockId)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+
+	if err != nil {
+		return fmt.Errorf("falha ao criar schema_migrations: %v", err)
+	}
+
+	return run(ctx, conn)
+}
+
+// loadAndVerify garante que as migrations já aplicadas não foram alteradas
+// nem removidas do binário.
+func loadAndVerify(ctx context.Context, conn *sql.Conn) ([]Migration, map[int64]time.Time, error) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checksums := make(map[int64]string, len(migrations))
+	for _, migration := range migrations {
+		checksums[migration.Version] = migration.Checksum
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+
+	for rows.Next() {
+		var version int64
+		var checksum string
+		var appliedAt time.Time
+
+		if err := rows.Scan(&version, &checksum, &appliedAt); err != nil {
+			return nil, nil, err
+		}
+
+		expected, ok := checksums[version]
+
+		if !ok {
+			return nil, nil, fmt.Errorf("migration %04d aplicada no banco não existe no código", version)
+		}
+
+		if expected != checksum {
+			return nil, nil, fmt.Errorf("checksum da migration %04d difere do que foi aplicado", version)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return migrations, applied, rows.Err()
+}
+
+func runInTx(ctx context.Context, conn *sql.Conn, script string, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
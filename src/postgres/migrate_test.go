@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"qr-code-boost/src/postgres/postgrestest"
+)
+
+func TestLoadMigrationsParsesAndSortsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0010_add_index.up.sql":      {Data: []byte("CREATE INDEX x ON t (c);")},
+		"migrations/0002_create_t.up.sql":       {Data: []byte("CREATE TABLE t (c INT);")},
+		"migrations/0002_create_t.down.sql":     {Data: []byte("DROP TABLE t;")},
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 3 {
+		t.Fatalf("len = %d, want 3", len(migrations))
+	}
+
+	for i, version := range []int64{1, 2, 10} {
+		if migrations[i].Version != version {
+			t.Errorf("migrations[%d].Version = %d, want %d", i, migrations[i].Version, version)
+		}
+	}
+
+	create := migrations[1]
+	if create.Name != "create_t" || create.Up != "CREATE TABLE t (c INT);" || create.Down != "DROP TABLE t;" {
+		t.Errorf("migration = %+v", create)
+	}
+
+	// O checksum cobre apenas o up, que é o que fica registrado no banco
+	sum := sha256.Sum256([]byte("CREATE TABLE t (c INT);"))
+	if create.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("checksum = %s", create.Checksum)
+	}
+
+	if migrations[2].Down != "" {
+		t.Errorf("down = %q, want empty for a migration without a down file", migrations[2].Down)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"sem direção":     {"migrations/0001_create_users.sql": {}},
+		"direção errada":  {"migrations/0001_create_users.sideways.sql": {}},
+		"sem nome":        {"migrations/0001.up.sql": {}},
+		"versão inválida": {"migrations/abc_create_users.up.sql": {}},
+		"sem up":          {"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")}},
+	}
+
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMigrations(fsys); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+
+		if migration.Down == "" {
+			t.Errorf("migration %04d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+var appliedColumns = []string{"version", "checksum", "applied_at"}
+
+// expectStatus roteiriza o GetMigrationStatus até a leitura de schema_migrations.
+func expectStatus(fake *postgrestest.Fake, rows ...[]any) {
+	fake.Expect("pg_advisory_lock")
+	fake.Expect("CREATE TABLE IF NOT EXISTS schema_migrations")
+	fake.Expect("FROM schema_migrations").Rows(appliedColumns, rows...)
+	fake.Expect("pg_advisory_unlock")
+}
+
+func TestGetMigrationStatus(t *testing.T) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, fake := postgrestest.New(t)
+	appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectStatus(fake, []any{migrations[0].Version, migrations[0].Checksum, appliedAt})
+
+	status, err := GetMigrationStatus(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status) != len(migrations) {
+		t.Fatalf("len = %d, want %d", len(status), len(migrations))
+	}
+
+	if status[0].AppliedAt == nil || !status[0].AppliedAt.Equal(appliedAt) {
+		t.Errorf("status[0].AppliedAt = %v, want %v", status[0].AppliedAt, appliedAt)
+	}
+
+	for _, item := range status[1:] {
+		if item.AppliedAt != nil {
+			t.Errorf("migration %d reported as applied", item.Version)
+		}
+	}
+}
+
+func TestGetMigrationStatusRejectsChangedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		row  []any
+		want string
+	}{
+		"checksum alterado": {
+			row:  []any{migrations[0].Version, "outro-checksum", time.Now()},
+			want: "checksum",
+		},
+		"removida do código": {
+			row:  []any{int64(9999), migrations[0].Checksum, time.Now()},
+			want: "não existe no código",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			db, fake := postgrestest.New(t)
+			expectStatus(fake, tc.row)
+
+			_, err := GetMigrationStatus(db)
+
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// A 0001 adota a tabela users de bancos antigos, então revertê-la não pode
+// apagar nada.
+func TestUsersMigrationDownKeepsData(t *testing.T) {
+	migrations, err := LoadMigrations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down := strings.ToUpper(migrations[0].Down)
+
+	if migrations[0].Name != "create_users" || strings.Contains(down, "DROP") || !strings.Contains(down, "RAISE EXCEPTION") {
+		t.Errorf("0001 down = %s", migrations[0].Down)
+	}
+}
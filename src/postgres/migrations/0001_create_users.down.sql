@@ -0,0 +1,10 @@
+-- A 0001 adota a tabela users de bancos antigos, que já tinha dados antes
+-- das migrations existirem, e não dá para saber quais colunas ela criou.
+-- Apagar a tabela ou as colunas destruiria esses usuários, então esta
+-- migration não é revertida: o erro desfaz a transação e interrompe o
+-- MigrateDown aqui.
+DO $$
+BEGIN
+    RAISE EXCEPTION 'a migration 0001_create_users não pode ser revertida sem perder os usuários existentes';
+END
+$$;
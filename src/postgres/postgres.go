@@ -3,73 +3,37 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"qr-code-boost/src/config"
+	"log/slog"
 
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-func ConnectionPostgres() (*sql.DB, error) {
-
-	databaseURL, err := config.GetEnvVariable("DATABASE_URL")
+func ConnectionPostgres(databaseURL string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(databaseURL)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("DATABASE_URL inválida: %v", err)
 	}
 
-	db, err := sql.Open("postgres", databaseURL)
-
-	if err != nil {
-		log.Fatal("Erro ao conectar:", err)
-		return nil, err
-	}
+	// Todas as chamadas passam pelo connector instrumentado, ver postgres_metrics.go
+	db := sql.OpenDB(instrumentedConnector{connector})
 
 	err = db.Ping()
 
 	if err != nil {
-		log.Fatal("Erro ao testar conexão:", err)
-		return nil, err
+		db.Close()
+		return nil, fmt.Errorf("falha ao verificar conexão: %v", err)
 	}
 
-	fmt.Println("------------------------")
-	fmt.Println("Conectado ao PostgreSQL!")
-	fmt.Println("------------------------")
+	slog.Info("Conexão com PostgreSQL estabelecida")
 
 	return db, nil
 }
 
-type Band struct {
-	id   string `bson:"id,omitempty"`
-	name string `bson:"name"`
-}
-
-func GetBands(db *sql.DB) ([]Band, error) {
-	var bands []Band
-
-	var query = `
-		SELECT * FROM bands
-	`
-
-	rows, err := db.Query(query)
-
-	if err != nil {
-		log.Printf("Erro na query de busca: %v", err)
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		var band Band
-
-		err := rows.Scan(&band.id, &band.name)
-		if err != nil {
-			log.Printf("Erro ao escanear linha.")
-			return nil, err
-		}
-
-		bands = append(bands, band)
-	}
-
-	return bands, nil
+// IsUUID diz se value é um UUID no formato canônico. Ids recebidos de fora
+// são conferidos antes de chegar a uma coluna UUID, onde um valor malformado
+// seria um erro do banco e viraria 500.
+func IsUUID(value string) bool {
+	return len(value) == 36 && uuid.Validate(value) == nil
 }
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"qr-code-boost/src/metrics"
+	"qr-code-boost/src/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queryDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "postgres",
+		Name:      "query_duration_seconds",
+		Help:      "Duração das chamadas ao PostgreSQL por operação (query, exec, begin, commit...).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	queryErrors = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "postgres",
+		Name:      "query_errors_total",
+		Help:      "Chamadas ao PostgreSQL que falharam.",
+	}, []string{"operation"})
+)
+
+// instrument mede uma chamada ao driver e abre o span dela (ver startSpan). A
+// função devolvida deve ser chamada com o resultado quando a chamada terminar.
+func instrument(ctx context.Context, operation, query string) func(err error) {
+	start := time.Now()
+	span := startSpan(ctx, operation, query)
+
+	return func(err error) {
+		// ErrSkip só pede ao database/sql para tentar outro caminho
+		if errors.Is(err, driver.ErrSkip) {
+			span.End()
+			return
+		}
+
+		queryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			queryErrors.WithLabelValues(operation).Inc()
+		}
+
+		tracing.End(span, err)
+	}
+}
+
+// instrumentedConnector envolve o connector do lib/pq para medir e rastrear as
+// chamadas. O database/sql não tem hooks, então a instrumentação fica no nível
+// do driver e cobre todos os pacotes que usam o *sql.DB.
+type instrumentedConnector struct {
+	driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	done := instrument(ctx, "connect", "")
+	conn, err := c.Connector.Connect(ctx)
+	done(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{conn: conn}, nil
+}
+
+// instrumentedConn repassa as interfaces opcionais que o lib/pq implementa;
+// quando a conexão não implementa alguma, devolve driver.ErrSkip e o
+// database/sql usa o caminho alternativo.
+type instrumentedConn struct {
+	conn driver.Conn
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	done := instrument(ctx, "prepare", query)
+
+	var stmt driver.Stmt
+	var err error
+
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+
+	done(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{stmt: stmt, query: query}, nil
+}
+
+func (c *instrumentedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *instrumentedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	done := instrument(ctx, "begin", "")
+
+	var tx driver.Tx
+	var err error
+
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.conn.Begin()
+	}
+
+	done(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedTx{tx: tx, ctx: ctx}, nil
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	done := instrument(ctx, "query", query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	done(err)
+
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	done := instrument(ctx, "exec", query)
+	result, err := execer.ExecContext(ctx, query, args)
+	done(err)
+
+	return result, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	pinger, ok := c.conn.(driver.Pinger)
+
+	if !ok {
+		return nil
+	}
+
+	done := instrument(ctx, "ping", "")
+	err := pinger.Ping(ctx)
+	done(err)
+
+	return err
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// instrumentedTx guarda o contexto do BeginTx para que o commit e o rollback
+// fiquem no mesmo trace da transação.
+type instrumentedTx struct {
+	tx  driver.Tx
+	ctx context.Context
+}
+
+func (t *instrumentedTx) Commit() error {
+	done := instrument(t.ctx, "commit", "")
+	err := t.tx.Commit()
+	done(err)
+
+	return err
+}
+
+func (t *instrumentedTx) Rollback() error {
+	done := instrument(t.ctx, "rollback", "")
+	err := t.tx.Rollback()
+	done(err)
+
+	return err
+}
+
+type instrumentedStmt struct {
+	stmt  driver.Stmt
+	query string
+}
+
+func (s *instrumentedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *instrumentedStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	done := instrument(context.Background(), "exec", s.query)
+	result, err := s.stmt.Exec(args)
+	done(err)
+
+	return result, err
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	done := instrument(context.Background(), "query", s.query)
+	rows, err := s.stmt.Query(args)
+	done(err)
+
+	return rows, err
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.stmt.(driver.StmtExecContext)
+
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+
+	done := instrument(ctx, "exec", s.query)
+	result, err := execer.ExecContext(ctx, args)
+	done(err)
+
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.stmt.(driver.StmtQueryContext)
+
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+
+	done := instrument(ctx, "query", s.query)
+	rows, err := queryer.QueryContext(ctx, args)
+	done(err)
+
+	return rows, err
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("parâmetros nomeados não são suportados")
+		}
+		values[i] = arg.Value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeConn responde a qualquer query com uma linha contendo 1 e falha nos execs.
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, errors.New("relation does not exist")
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                            { return nil }
+
+func TestInstrumentedConnectorObservesCalls(t *testing.T) {
+	db := sql.OpenDB(instrumentedConnector{fakeConnector{}})
+	defer db.Close()
+
+	execErrors := testutil.ToFloat64(queryErrors.WithLabelValues("exec"))
+
+	var n int
+	if err := db.QueryRow("SELECT 1").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("query through wrapper = %d, %v", n, err)
+	}
+
+	if _, err := db.Exec("DELETE FROM missing"); err == nil {
+		t.Fatal("exec error was swallowed by the wrapper")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+
+	if got := testutil.ToFloat64(queryErrors.WithLabelValues("exec")) - execErrors; got != 1 {
+		t.Errorf("exec errors = %v, want 1", got)
+	}
+
+	for _, operation := range []string{"connect", "query", "exec", "begin", "commit"} {
+		var metric dto.Metric
+		queryDuration.WithLabelValues(operation).(prometheus.Metric).Write(&metric)
+
+		if metric.GetHistogram().GetSampleCount() == 0 {
+			t.Errorf("no duration observed for %s", operation)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"qr-code-boost/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// startSpan só abre spans para chamadas feitas dentro de um trace amostrado;
+// as demais, como as migrations, recebem um span que não grava nada. O nome
+// do span é o comando SQL (SELECT, INSERT...) ou a operação do driver.
+func startSpan(ctx context.Context, operation, query string) trace.Span {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return noop.Span{}
+	}
+
+	name := strings.ToUpper(operation)
+	attributes := []attribute.KeyValue{semconv.DBSystemNamePostgreSQL}
+
+	if query != "" {
+		if fields := strings.Fields(query); len(fields) > 0 {
+			name = strings.ToUpper(fields[0])
+		}
+
+		// As queries usam parâmetros ($1, $2...), então o texto não leva valores
+		attributes = append(attributes, semconv.DBQueryText(query))
+	}
+
+	attributes = append(attributes, semconv.DBOperationName(name))
+
+	_, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+
+	return span
+}
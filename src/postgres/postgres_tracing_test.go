@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentedConnectorTracesOnlyWithinTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	db := sql.OpenDB(instrumentedConnector{fakeConnector{}})
+	defer db.Close()
+
+	// Fora de um trace, como nas migrations, nenhum span é criado
+	var n int
+	if err := db.QueryRowContext(context.Background(), "SELECT 1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Fatalf("spans outside a trace = %d, want 0", len(spans))
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	if err := db.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1", "u1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	db.ExecContext(ctx, "DELETE FROM missing")
+	parent.End()
+
+	statuses := map[string]string{}
+	for _, span := range recorder.Ended() {
+		if span.Name() == "request" {
+			continue
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the request span", span.Name())
+		}
+		statuses[span.Name()] = span.Status().Code.String()
+	}
+
+	if statuses["SELECT"] != "Unset" || statuses["DELETE"] != "Error" {
+		t.Errorf("span statuses = %v, want SELECT ok and DELETE failed", statuses)
+	}
+}
@@ -0,0 +1,233 @@
+// Package postgrestest fornece um driver database/sql roteirizado para testar
+// o SQL dos serviços sem um Postgres: cada query executada deve corresponder,
+// na ordem, a uma expectativa registrada com Expect.
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Expectation é uma query esperada e o que ela deve responder.
+type Expectation struct {
+	contains     string
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+	err          error
+}
+
+// Rows define as linhas devolvidas por uma query.
+func (e *Expectation) Rows(columns []string, rows ...[]any) *Expectation {
+	e.columns = columns
+
+	for _, row := range rows {
+		values := make([]driver.Value, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+		e.rows = append(e.rows, values)
+	}
+
+	return e
+}
+
+// RowsAffected define o resultado de um exec.
+func (e *Expectation) RowsAffected(n int64) *Expectation {
+	e.rowsAffected = n
+	return e
+}
+
+// Error faz a query falhar com err.
+func (e *Expectation) Error(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Call registra uma query executada e se ela estava dentro de uma transação.
+type Call struct {
+	Query string
+	Args  []any
+	InTx  bool
+}
+
+type Fake struct {
+	t testing.TB
+
+	mu        sync.Mutex
+	expected  []*Expectation
+	calls     []Call
+	inTx      bool
+	commits   int
+	rollbacks int
+}
+
+// New abre um *sql.DB sobre o driver roteirizado. Ao fim do teste ele falha se
+// alguma expectativa não foi consumida.
+func New(t testing.TB) (*sql.DB, *Fake) {
+	t.Helper()
+
+	fake := &Fake{t: t}
+	db := sql.OpenDB(connector{fake})
+
+	t.Cleanup(func() {
+		db.Close()
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		for _, expectation := range fake.expected {
+			t.Errorf("query não executada: %q", expectation.contains)
+		}
+	})
+
+	return db, fake
+}
+
+// Expect registra a próxima query esperada, identificada por um trecho do SQL.
+func (f *Fake) Expect(contains string) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expectation := &Expectation{contains: contains}
+	f.expected = append(f.expected, expectation)
+
+	return expectation
+}
+
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call{}, f.calls...)
+}
+
+func (f *Fake) Commits() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.commits
+}
+
+func (f *Fake) Rollbacks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.rollbacks
+}
+
+func (f *Fake) next(query string, args []driver.NamedValue) (*Expectation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	f.calls = append(f.calls, Call{Query: query, Args: values, InTx: f.inTx})
+
+	if len(f.expected) == 0 {
+		f.t.Errorf("query inesperada: %s", query)
+		return nil, fmt.Errorf("postgrestest: query inesperada")
+	}
+
+	expectation := f.expected[0]
+
+	if !strings.Contains(query, expectation.contains) {
+		f.t.Errorf("query = %s\nesperada uma contendo %q", query, expectation.contains)
+		return nil, fmt.Errorf("postgrestest: query fora de ordem")
+	}
+
+	f.expected = f.expected[1:]
+
+	return expectation, expectation.err
+}
+
+type connector struct{ fake *Fake }
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) { return conn(c), nil }
+func (c connector) Driver() driver.Driver                            { return nil }
+
+type conn struct{ fake *Fake }
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("postgrestest: prepare não suportado")
+}
+
+func (c conn) Close() error              { return nil }
+func (c conn) Begin() (driver.Tx, error) { return c.BeginTx(context.Background(), driver.TxOptions{}) }
+
+func (c conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+
+	c.fake.inTx = true
+	return tx(c), nil
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	expectation, err := c.fake.next(query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{columns: expectation.columns, values: expectation.rows}, nil
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	expectation, err := c.fake.next(query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(expectation.rowsAffected), nil
+}
+
+type tx struct{ fake *Fake }
+
+func (t tx) Commit() error {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	t.fake.inTx = false
+	t.fake.commits++
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	t.fake.inTx = false
+	t.fake.rollbacks++
+	return nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
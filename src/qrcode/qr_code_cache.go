@@ -0,0 +1,292 @@
+package qrcode
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SlugCacheStats struct {
+	Size          int     `json:"size"`
+	Capacity      int     `json:"capacity"`
+	Hits          uint64  `json:"hits"`
+	NegativeHits  uint64  `json:"negativeHits"` // Slugs inexistentes respondidos pelo cache
+	Misses        uint64  `json:"misses"`
+	Evictions     uint64  `json:"evictions"`
+	Invalidations uint64  `json:"invalidations"`
+	HitRate       float64 `json:"hitRate"` // (hits + negativeHits) / consultas
+}
+
+// CachedQRCode é o que o cache sabe sobre um slug: o QR Code ou que ele não
+// existe (NotFound).
+type CachedQRCode struct {
+	QRCode   models.QRCode
+	NotFound bool
+}
+
+type slugCacheEntry struct {
+	slug      string
+	value     CachedQRCode
+	expiresAt time.Time
+}
+
+// SlugCache guarda slug -> QR Code para o redirecionamento não consultar o
+// MongoDB a cada scan. As entradas menos usadas saem quando a capacidade é
+// atingida e todas expiram pelo TTL, o que limita o tempo de uma entrada
+// desatualizada quando a invalidação entre réplicas não está disponível.
+type SlugCache struct {
+	opts config.SlugCacheConfig
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List                    // Mais recente na frente
+	slugs   map[primitive.ObjectID]string // Remoções no change stream trazem só o ID
+
+	// generation muda a cada invalidação; veja Generation
+	generation uint64
+
+	hits          atomic.Uint64
+	negativeHits  atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+func NewSlugCache(opts config.SlugCacheConfig) *SlugCache {
+	return &SlugCache{
+		opts:    opts,
+		now:     time.Now,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		slugs:   map[primitive.ObjectID]string{},
+	}
+}
+
+func (c *SlugCache) Enabled() bool {
+	return c.opts.Capacity > 0
+}
+
+func (c *SlugCache) Get(slug string) (CachedQRCode, bool) {
+	if !c.Enabled() {
+		return CachedQRCode{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[slug]
+
+	if !ok {
+		c.misses.Add(1)
+		return CachedQRCode{}, false
+	}
+
+	entry := element.Value.(*slugCacheEntry)
+
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		c.misses.Add(1)
+		return CachedQRCode{}, false
+	}
+
+	c.order.MoveToFront(element)
+
+	if entry.value.NotFound {
+		c.negativeHits.Add(1)
+	} else {
+		c.hits.Add(1)
+	}
+
+	return entry.value, true
+}
+
+// Generation deve ser lida antes de buscar no banco o valor a guardar com
+// SetSince ou SetNotFoundSince. Se houver uma invalidação durante a busca, o
+// resultado, possivelmente anterior a ela, é descartado.
+func (c *SlugCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *SlugCache) Set(qrCode models.QRCode) {
+	c.SetSince(qrCode, c.Generation())
+}
+
+func (c *SlugCache) SetNotFound(slug string) {
+	c.SetNotFoundSince(slug, c.Generation())
+}
+
+func (c *SlugCache) SetSince(qrCode models.QRCode, generation uint64) {
+	c.set(qrCode.Slug, CachedQRCode{QRCode: qrCode}, c.opts.TTL, generation)
+}
+
+func (c *SlugCache) SetNotFoundSince(slug string, generation uint64) {
+	c.set(slug, CachedQRCode{NotFound: true}, c.opts.NegativeTTL, generation)
+}
+
+func (c *SlugCache) set(slug string, value CachedQRCode, ttl time.Duration, generation uint64) {
+	if !c.Enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.entries[slug]; ok {
+		c.remove(element)
+	}
+
+	element := c.order.PushFront(&slugCacheEntry{
+		slug:      slug,
+		value:     value,
+		expiresAt: c.now().Add(ttl),
+	})
+
+	c.entries[slug] = element
+
+	if !value.NotFound {
+		c.slugs[value.QRCode.ID] = slug
+	}
+
+	for c.order.Len() > c.opts.Capacity {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Invalidate remove o slug, existente ou não, do cache.
+func (c *SlugCache) Invalidate(slug string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	if element, ok := c.entries[slug]; ok {
+		c.remove(element)
+		c.invalidations.Add(1)
+	}
+}
+
+func (c *SlugCache) InvalidateId(id primitive.ObjectID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Mesmo sem o ID no cache, uma busca em andamento pode trazer a versão antiga
+	c.generation++
+
+	if slug, ok := c.slugs[id]; ok {
+		c.remove(c.entries[slug])
+		c.invalidations.Add(1)
+	}
+}
+
+// Purge esvazia o cache. Usado quando eventos de invalidação podem ter sido
+// perdidos.
+func (c *SlugCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+	c.slugs = map[primitive.ObjectID]string{}
+}
+
+func (c *SlugCache) Stats() SlugCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	stats := SlugCacheStats{
+		Size:          size,
+		Capacity:      c.opts.Capacity,
+		Hits:          c.hits.Load(),
+		NegativeHits:  c.negativeHits.Load(),
+		Misses:        c.misses.Load(),
+		Evictions:     c.evictions.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+
+	if lookups := stats.Hits + stats.NegativeHits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits+stats.NegativeHits) / float64(lookups)
+	}
+
+	return stats
+}
+
+// remove deve ser chamado com mu travado.
+func (c *SlugCache) remove(element *list.Element) {
+	entry := element.Value.(*slugCacheEntry)
+
+	c.order.Remove(element)
+	delete(c.entries, entry.slug)
+
+	if !entry.value.NotFound && c.slugs[entry.value.QRCode.ID] == entry.slug {
+		delete(c.slugs, entry.value.QRCode.ID)
+	}
+}
+
+type QRCodeWatcher interface {
+	Watch(ctx context.Context, onChange func(repositories.QRCodeChange)) error
+}
+
+// WatchSlugCache invalida o cache com as alterações feitas por qualquer
+// réplica até ctx ser cancelado. Se o stream cair, o cache é esvaziado antes
+// de reconectar, já que eventos podem ter sido perdidos no intervalo. Sem
+// replica set o cache fica apenas com a expiração por TTL.
+func WatchSlugCache(ctx context.Context, watcher QRCodeWatcher, cache *SlugCache) {
+	if !cache.Enabled() {
+		return
+	}
+
+	backoff := time.Second
+
+	for {
+		err := watcher.Watch(ctx, func(change repositories.QRCodeChange) {
+			cache.InvalidateId(change.Id)
+
+			// Inserções e trocas de slug derrubam também a entrada negativa do slug novo
+			if change.Slug != "" {
+				cache.Invalidate(change.Slug)
+			}
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, repositories.ErrChangeStreamsUnsupported) {
+			slog.Warn("MongoDB sem replica set: o cache de slugs será invalidado entre réplicas apenas pelo TTL")
+			return
+		}
+
+		slog.Error("Change stream de QR Codes interrompido", "retryIn", backoff, logging.Err(err))
+		cache.Purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, time.Minute)
+	}
+}
@@ -0,0 +1,48 @@
+package qrcode
+
+import (
+	"qr-code-boost/src/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func slugCacheDesc(name string, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "slug_cache", name), help, nil, nil)
+}
+
+var (
+	slugCacheSize          = slugCacheDesc("size", "Entradas no cache de slugs.")
+	slugCacheCapacity      = slugCacheDesc("capacity", "Capacidade do cache de slugs.")
+	slugCacheHits          = slugCacheDesc("hits_total", "Slugs encontrados no cache.")
+	slugCacheNegativeHits  = slugCacheDesc("negative_hits_total", "Slugs inexistentes respondidos pelo cache.")
+	slugCacheMisses        = slugCacheDesc("misses_total", "Consultas ao cache que precisaram ir ao MongoDB.")
+	slugCacheEvictions     = slugCacheDesc("evictions_total", "Entradas removidas por falta de espaço.")
+	slugCacheInvalidations = slugCacheDesc("invalidations_total", "Entradas removidas por alteração do QR Code.")
+	slugCacheHitRatio      = slugCacheDesc("hit_ratio", "Fração das consultas atendidas pelo cache desde o início do processo.")
+)
+
+// Describe e Collect fazem do SlugCache um prometheus.Collector. Para a taxa
+// em uma janela, prefira rate() sobre os contadores de hits e misses.
+func (c *SlugCache) Describe(ch chan<- *prometheus.Desc) {
+	ch <- slugCacheSize
+	ch <- slugCacheCapacity
+	ch <- slugCacheHits
+	ch <- slugCacheNegativeHits
+	ch <- slugCacheMisses
+	ch <- slugCacheEvictions
+	ch <- slugCacheInvalidations
+	ch <- slugCacheHitRatio
+}
+
+func (c *SlugCache) Collect(ch chan<- prometheus.Metric) {
+	stats := c.Stats()
+
+	ch <- prometheus.MustNewConstMetric(slugCacheSize, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(slugCacheCapacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(slugCacheHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(slugCacheNegativeHits, prometheus.CounterValue, float64(stats.NegativeHits))
+	ch <- prometheus.MustNewConstMetric(slugCacheMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(slugCacheEvictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(slugCacheInvalidations, prometheus.CounterValue, float64(stats.Invalidations))
+	ch <- prometheus.MustNewConstMetric(slugCacheHitRatio, prometheus.GaugeValue, stats.HitRate)
+}
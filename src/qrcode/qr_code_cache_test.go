@@ -0,0 +1,186 @@
+package qrcode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/repositories"
+)
+
+func newTestSlugCache(capacity int) (*SlugCache, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cache := NewSlugCache(config.SlugCacheConfig{
+		Capacity:    capacity,
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+	})
+	cache.now = func() time.Time { return now }
+
+	return cache, &now
+}
+
+func TestSlugCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, _ := newTestSlugCache(2)
+
+	cache.Set(seededQRCode("a", ownerId))
+	cache.Set(seededQRCode("b", ownerId))
+
+	// "a" passa a ser o mais recente, então "b" sai quando "c" entra
+	cache.Get("a")
+	cache.Set(seededQRCode("c", ownerId))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+
+	for _, slug := range []string{"a", "c"} {
+		if _, ok := cache.Get(slug); !ok {
+			t.Errorf("%s should be cached", slug)
+		}
+	}
+
+	if stats := cache.Stats(); stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("stats = %+v, want size 2 and 1 eviction", stats)
+	}
+}
+
+func TestSlugCacheExpiresEntries(t *testing.T) {
+	cache, now := newTestSlugCache(10)
+
+	cache.Set(seededQRCode("promo", ownerId))
+	cache.SetNotFound("missing")
+
+	*now = now.Add(30 * time.Second)
+
+	if _, ok := cache.Get("promo"); !ok {
+		t.Error("promo expired before its TTL")
+	}
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("negative entry should expire after the negative TTL")
+	}
+
+	*now = now.Add(time.Minute)
+
+	if _, ok := cache.Get("promo"); ok {
+		t.Error("promo should expire after the TTL")
+	}
+}
+
+func TestSlugCacheInvalidateId(t *testing.T) {
+	cache, _ := newTestSlugCache(10)
+
+	qrCode := seededQRCode("promo", ownerId)
+	cache.Set(qrCode)
+
+	cache.InvalidateId(qrCode.ID)
+
+	if _, ok := cache.Get("promo"); ok {
+		t.Error("promo should be invalidated by id")
+	}
+}
+
+func TestSlugCacheDropsFillsStartedBeforeInvalidation(t *testing.T) {
+	cache, _ := newTestSlugCache(10)
+	qrCode := seededQRCode("promo", ownerId)
+
+	// A busca no banco começa, o QR Code é alterado e invalidado, e só então
+	// a busca termina com a versão antiga
+	generation := cache.Generation()
+	cache.Invalidate("promo")
+	cache.SetSince(qrCode, generation)
+	cache.SetNotFoundSince("missing", generation)
+
+	if _, ok := cache.Get("promo"); ok {
+		t.Error("stale lookup was cached after the invalidation")
+	}
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("stale negative lookup was cached after the invalidation")
+	}
+
+	cache.SetSince(qrCode, cache.Generation())
+
+	if _, ok := cache.Get("promo"); !ok {
+		t.Error("lookup started after the invalidation was not cached")
+	}
+}
+
+func TestSlugCacheStats(t *testing.T) {
+	cache, _ := newTestSlugCache(10)
+
+	cache.Get("promo")
+	cache.Set(seededQRCode("promo", ownerId))
+	cache.Get("promo")
+	cache.SetNotFound("missing")
+	cache.Get("missing")
+
+	stats := cache.Stats()
+
+	if stats.Hits != 1 || stats.NegativeHits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 hit, 1 negative hit and 1 miss", stats)
+	}
+
+	if stats.HitRate < 0.66 || stats.HitRate > 0.67 {
+		t.Errorf("hit rate = %f, want 2/3", stats.HitRate)
+	}
+}
+
+func TestSlugCacheDisabled(t *testing.T) {
+	cache, _ := newTestSlugCache(0)
+
+	cache.Set(seededQRCode("promo", ownerId))
+
+	if _, ok := cache.Get("promo"); ok {
+		t.Error("disabled cache returned an entry")
+	}
+}
+
+type fakeWatcher struct {
+	changes []repositories.QRCodeChange
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, onChange func(repositories.QRCodeChange)) error {
+	for _, change := range w.changes {
+		onChange(change)
+	}
+
+	return repositories.ErrChangeStreamsUnsupported
+}
+
+func TestWatchSlugCacheInvalidatesChangedQRCodes(t *testing.T) {
+	cache, _ := newTestSlugCache(10)
+
+	updated := seededQRCode("updated", ownerId)
+	deleted := seededQRCode("deleted", ownerId)
+	kept := seededQRCode("kept", ownerId)
+
+	cache.Set(updated)
+	cache.Set(deleted)
+	cache.Set(kept)
+	cache.SetNotFound("created")
+
+	created := seededQRCode("created", ownerId)
+
+	watcher := &fakeWatcher{changes: []repositories.QRCodeChange{
+		{Operation: "update", Id: updated.ID, Slug: updated.Slug},
+		{Operation: "delete", Id: deleted.ID},
+		{Operation: "insert", Id: created.ID, Slug: created.Slug},
+	}}
+
+	// Retorna assim que o watcher informa que não há change streams
+	WatchSlugCache(t.Context(), watcher, cache)
+
+	for _, slug := range []string{"updated", "deleted", "created"} {
+		if _, ok := cache.Get(slug); ok {
+			t.Errorf("%s should be invalidated", slug)
+		}
+	}
+
+	if _, ok := cache.Get("kept"); !ok {
+		t.Error("kept should still be cached")
+	}
+}
@@ -2,9 +2,19 @@ package qrcode
 
 import (
 	"database/sql"
-	"fmt"
-	"os"
+	"log/slog"
+	"net/netip"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/clientip"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/metrics"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+	"qr-code-boost/src/scan"
+	"qr-code-boost/src/workspace"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,16 +26,25 @@ type CreateQRCodeDto struct {
 	Lat    float64 `json:"lat" binding:"required,latitude"`
 	Long   float64 `json:"long" binding:"required,longitude"`
 	UserId string  `json:"userId" binding:"required,uuid"`
+
+	WorkspaceId string `json:"-"`
 }
 
+// QRCodeController recebe os clientes de banco apenas para os middlewares
+// registrados em QRCodesRouter; as regras de negócio ficam em Service.
 type QRCodeController struct {
-	MongoClient    *mongo.Client
+	Service        *QRCodeService
+	MongoDatabase  *mongo.Database
 	PostgresClient *sql.DB
 }
 
-type CoordinatesDto struct {
-	Lat  *float64 `json:"lat"`
-	Long *float64 `json:"long"`
+type AccessQRCodeDto struct {
+	Lat   *float64
+	Long  *float64
+	IsBot bool
+
+	ClientIP  netip.Addr
+	UserAgent string
 }
 
 // @Summary      Find QR Code by Slug
@@ -33,16 +52,20 @@ type CoordinatesDto struct {
 // @Accept       json
 // @Produce      json
 // @Param        slug path string true "QR Code Slug"
-// @Success      200 {object} qrcode.QRCodeWithURL
-// @Router       /qr/{slug} [get]
+// @Success      200 {object} models.QRCode
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Router       /{slug} [get]
 func (u *QRCodeController) AccessQRCode(c *gin.Context) {
+	// Conta o acesso pelo status efetivamente respondido
+	defer func() {
+		metrics.Redirects.WithLabelValues(strconv.Itoa(c.Writer.Status())).Inc()
+	}()
+
 	slug := c.Param("slug")
 
 	if slug == "" {
-		c.IndentedJSON(400, gin.H{
-			"message": "Slug não pode ser vazio.",
-			"status":  400,
-		})
+		apperror.Respond(c, apperror.New(apperror.Invalid, "request.empty_param", "slug"))
 		return
 	}
 
@@ -65,19 +88,18 @@ func (u *QRCodeController) AccessQRCode(c *gin.Context) {
 		}
 	}
 
-	coordinates := CoordinatesDto{
-		Lat:  &latitude,
-		Long: &longitude,
+	accessDto := AccessQRCodeDto{
+		Lat:       &latitude,
+		Long:      &longitude,
+		IsBot:     scan.IsBotRequest(c.Request),
+		ClientIP:  clientip.FromContext(c),
+		UserAgent: c.Request.UserAgent(),
 	}
 
-	qrCode, err := AccessQRCode(slug, coordinates, u.MongoClient)
+	qrCode, err := u.Service.AccessQRCode(c.Request.Context(), slug, accessDto)
 
 	if err != nil {
-		fmt.Printf("Erro ao buscar QR Code: %v", err)
-		c.IndentedJSON(500, gin.H{
-			"message": "Erro ao buscar QR Code",
-			"error":   err.Error(),
-		})
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -90,25 +112,55 @@ func (u *QRCodeController) AccessQRCode(c *gin.Context) {
 // @Produce      json
 // @Param        userId   path      string  true  "User ID"
 // @Success      200  {array}   qrcode.QRCodeWithURL
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /qr/user/{userId} [get]
 func (u *QRCodeController) FindAllQRCodes(c *gin.Context) {
-	os.MkdirAll("./static/images", os.ModePerm)
-
 	userId := c.Param("userId")
 
 	if userId == "" {
-		c.IndentedJSON(400, gin.H{
-			"message": "User ID não pode ser vazio.",
-			"status":  400,
-		})
+		apperror.Respond(c, apperror.New(apperror.Invalid, "request.empty_param", "userId"))
+		return
+	}
+
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.CanAccess(userId) {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "qrcode.forbidden_list"))
+		return
+	}
+
+	qrCodes, err := u.Service.FindAll(c.Request.Context(), userId)
+
+	if err != nil {
+		apperror.Respond(c, err)
 		return
 	}
 
-	qrCodes, err := FindAll(userId, u.MongoClient)
+	c.IndentedJSON(200, qrCodes)
+}
+
+// @Summary      List QR Codes from a workspace
+// @Tags         QR Codes
+// @Accept       json
+// @Produce      json
+// @Param        workspaceId   path      string  true  "Workspace ID"
+// @Success      200  {array}   qrcode.QRCodeWithURL
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /qr/workspace/{workspaceId} [get]
+func (u *QRCodeController) FindAllWorkspaceQRCodes(c *gin.Context) {
+	qrCodes, err := u.Service.FindAllByWorkspace(c.Request.Context(), c.Param("workspaceId"))
 
 	if err != nil {
-		fmt.Printf("Erro ao listar QR Codes: %v", err)
-		c.IndentedJSON(500, err)
+		apperror.Respond(c, err)
 		return
 	}
 
@@ -120,7 +172,18 @@ func (u *QRCodeController) FindAllQRCodes(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        request body qrcode.CreateQRCodeDto true "QR Code Payload"
+// @Param        Idempotency-Key header string false "Key that makes retried creates replay the first response"
+// @Param        X-Workspace-ID header string false "Workspace that will own the QR Code"
 // @Success      201  {object}  qrcode.QRCodeWithURL
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      409 {object} apperror.Problem
+// @Failure      422 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /qr [post]
 func (u *QRCodeController) CreateQRCode(c *gin.Context) {
 	var createQRCodeDto CreateQRCodeDto
@@ -128,26 +191,29 @@ func (u *QRCodeController) CreateQRCode(c *gin.Context) {
 	err := c.ShouldBindJSON(&createQRCodeDto)
 
 	if err != nil {
-		fmt.Printf("Corpo da requisição inválido | %v", err)
-		c.IndentedJSON(400, gin.H{
-			"message": "Corpo da requisição inválido.",
-			"status":  400,
-		})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
-	qrCodeWithURL, errCreating := Create(createQRCodeDto, u.MongoClient, u.PostgresClient)
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.CanAccess(createQRCodeDto.UserId) {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "qrcode.forbidden_create"))
+		return
+	}
+
+	if member, ok := workspace.CurrentMember(c); ok {
+		createQRCodeDto.WorkspaceId = member.WorkspaceId
+	}
+
+	qrCodeWithURL, errCreating := u.Service.Create(c.Request.Context(), createQRCodeDto)
 
 	if errCreating != nil {
-		fmt.Printf("Erro ao criar QR Code: %v", errCreating)
-		c.IndentedJSON(500, gin.H{
-			"message": "Erro ao criar QR Code",
-			"error":   errCreating.Error(),
-		})
+		apperror.Respond(c, errCreating)
 		return
 	}
 
-	c.IndentedJSON(200, qrCodeWithURL)
+	c.IndentedJSON(201, qrCodeWithURL)
 }
 
 // @Summary      Find scans near a QR Code
@@ -155,49 +221,155 @@ func (u *QRCodeController) CreateQRCode(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        slug path string true "QR Code Slug"
-// @Param 			 maxDistance query int false "Maximum distance in meters (default: 3000 or 3km)"
+// @Param        X-Workspace-ID header string false "Workspace the QR Code belongs to"
+// @Param 			 maxDistance query int false "Maximum distance in meters (default: configured radius, 3000 out of the box)"
 // @Success      200 {array} models.Scan
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /qr/near/{slug} [get]
 func (u *QRCodeController) FindNearScans(c *gin.Context) {
 	slug := c.Param("slug")
 
 	if slug == "" {
-		c.IndentedJSON(400, gin.H{
-			"message": "Slug não pode ser vazio.",
-			"status":  400,
-		})
+		apperror.Respond(c, apperror.New(apperror.Invalid, "request.empty_param", "slug"))
 		return
 	}
 
-	var maxDistance int64 = 3000
+	var maxDistance *int64
 
 	maxDistanceQuery := c.Query("maxDistance")
 	if maxDistanceQuery != "" {
 		parsedDistance, errQuery := strconv.ParseInt(maxDistanceQuery, 10, 64)
 
 		if errQuery != nil {
-			fmt.Printf("Erro ao converter maxDistance: %v", errQuery)
-			c.IndentedJSON(400, gin.H{
-				"message": "Parâmetro maxDistance inválido.",
-				"status":  400,
-			})
+			slog.InfoContext(c.Request.Context(), "Parâmetro maxDistance inválido", logging.Err(errQuery))
+			apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_param", "maxDistance"))
 			return
 		}
 
-		maxDistance = parsedDistance
+		maxDistance = &parsedDistance
+	}
+
+	qrCode, err := u.Service.FindBySlug(c.Request.Context(), slug)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if !canAccessQRCode(c, qrCode) {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "qrcode.forbidden_access"))
+		return
 	}
 
-	fmt.Printf("\n Buscando scans próximos para o QR Code com slug: %s\n", slug)
-	scans, err := FindNearScans(slug, maxDistance, u.MongoClient)
+	scans, err := u.Service.FindNearScans(c.Request.Context(), qrCode, maxDistance)
 
 	if err != nil {
-		fmt.Printf("Erro ao buscar scans próximos: %v", err)
-		c.IndentedJSON(500, gin.H{
-			"message": "Erro ao buscar scans próximos",
-			"error":   err.Error(),
-		})
+		apperror.Respond(c, err)
 		return
 	}
 
 	c.IndentedJSON(200, scans)
 }
+
+// @Summary      Scan statistics of a QR Code
+// @Description  Daily total and unique-visitor scan counts. Scans from bots and link previews are excluded unless includeBots=true.
+// @Description  Visitor IDs rotate daily, so the period's unique count is the sum of daily uniques.
+// @Tags         QR Codes
+// @Accept       json
+// @Produce      json
+// @Param        slug path string true "QR Code Slug"
+// @Param        X-Workspace-ID header string false "Workspace the QR Code belongs to"
+// @Param        from query string false "First day (YYYY-MM-DD, UTC). Defaults to 30 days before 'to'"
+// @Param        to query string false "Last day, inclusive (YYYY-MM-DD, UTC). Defaults to now"
+// @Param        includeBots query bool false "Count scans classified as bots"
+// @Success      200 {object} scan.ScanStats
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /qr/stats/{slug} [get]
+func (u *QRCodeController) FindScanStats(c *gin.Context) {
+	var filter repositories.ScanStatsFilter
+
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+
+		if err != nil {
+			apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_date", "from"))
+			return
+		}
+
+		filter.From = parsed
+	}
+
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+
+		if err != nil {
+			apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_date", "to"))
+			return
+		}
+
+		filter.To = parsed.AddDate(0, 0, 1) // O dia informado entra inteiro
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		apperror.Respond(c, apperror.New(apperror.Invalid, "stats.invalid_range"))
+		return
+	}
+
+	if includeBots := c.Query("includeBots"); includeBots != "" {
+		parsed, err := strconv.ParseBool(includeBots)
+
+		if err != nil {
+			apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_param", "includeBots"))
+			return
+		}
+
+		filter.IncludeBots = parsed
+	}
+
+	qrCode, err := u.Service.FindBySlug(c.Request.Context(), c.Param("slug"))
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	if !canAccessQRCode(c, qrCode) {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "qrcode.forbidden_access"))
+		return
+	}
+
+	stats, err := u.Service.FindScanStats(c.Request.Context(), qrCode, filter)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, stats)
+}
+
+// canAccessQRCode libera o dono do QR Code, admins e membros do workspace do
+// QR Code já validados por workspace.RequirePermission.
+func canAccessQRCode(c *gin.Context, qrCode models.QRCode) bool {
+	principal, _ := auth.GetPrincipal(c)
+
+	if principal.CanAccess(qrCode.UserId) {
+		return true
+	}
+
+	member, ok := workspace.CurrentMember(c)
+
+	return ok && qrCode.WorkspaceId != "" && member.WorkspaceId == qrCode.WorkspaceId
+}
@@ -0,0 +1,627 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/config"
+	"qr-code-boost/src/metrics"
+	"qr-code-boost/src/middlewares"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+	"qr-code-boost/src/scan"
+	"qr-code-boost/src/storage"
+	"qr-code-boost/src/user"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ownerId    = "7f1c1b2e-8c5e-4a8a-9d0a-3f7c1e9b2a10"
+	strangerId = "0d6b8f3a-2c1e-4f5d-8b7a-9e6c5d4b3a21"
+	deletedId  = "5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d"
+
+	browserUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+)
+
+type testEnv struct {
+	router  *gin.Engine
+	qrCodes *repositories.InMemoryQRCodeRepository
+	scans   *repositories.InMemoryScanRepository
+	storage *storage.LocalStorage
+	service *QRCodeService
+}
+
+func newTestEnv(t *testing.T, principal *auth.Principal, qrCodes ...models.QRCode) *testEnv {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	imageStorage, err := storage.NewLocalStorage(t.TempDir(), "/images")
+	if err != nil {
+		t.Fatalf("creating storage: %v", err)
+	}
+
+	deletedAt := time.Now()
+	users := repositories.NewInMemoryUserRepository(
+		user.User{Id: ownerId, Name: "Owner"},
+		user.User{Id: strangerId, Name: "Stranger"},
+		user.User{Id: deletedId, Name: "Deleted", DeletedAt: &deletedAt},
+	)
+
+	cfg := config.Default()
+	cfg.WebURL = "https://qr.example.com"
+
+	qrCodeRepository := repositories.NewInMemoryQRCodeRepository(qrCodes...)
+	scanRepository := repositories.NewInMemoryScanRepository()
+
+	controller := &QRCodeController{
+		Service: NewQRCodeService(
+			qrCodeRepository,
+			users,
+			scan.NewScanService(
+				scanRepository,
+				nil,
+				scan.NewVisitorHasher(repositories.NewInMemoryVisitorSaltRepository()),
+				cfg.NearScans.DefaultMaxDistance,
+			),
+			imageStorage,
+			cfg,
+		),
+	}
+
+	router := gin.New()
+	router.Use(middlewares.LanguageMiddleware(), middlewares.RecoveryMiddleware())
+	router.GET("/:slug", controller.AccessQRCode)
+
+	authenticated := router.Group("/qr", func(c *gin.Context) {
+		if principal != nil {
+			auth.SetPrincipal(c, principal)
+		}
+		c.Next()
+	})
+	authenticated.POST("/", controller.CreateQRCode)
+	authenticated.GET("/near/:slug", controller.FindNearScans)
+	authenticated.GET("/user/:userId", controller.FindAllQRCodes)
+	authenticated.GET("/stats/:slug", controller.FindScanStats)
+
+	return &testEnv{
+		router:  router,
+		qrCodes: qrCodeRepository,
+		scans:   scanRepository,
+		storage: imageStorage,
+		service: controller.Service,
+	}
+}
+
+func (e *testEnv) do(method string, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+
+	request := httptest.NewRequest(method, path, &payload)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", browserUserAgent)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	e.router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func (e *testEnv) storedImages(t *testing.T) []string {
+	t.Helper()
+
+	objects, err := e.storage.List(t.Context())
+	if err != nil {
+		t.Fatalf("listing images: %v", err)
+	}
+
+	keys := []string{}
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+
+	return keys
+}
+
+func seededQRCode(slug string, userId string) models.QRCode {
+	return models.QRCode{
+		ID:     primitive.NewObjectID(),
+		Slug:   slug,
+		Link:   "https://example.com/" + slug,
+		UserId: userId,
+		Location: models.Location{
+			Type:        "Point",
+			Coordinates: []float64{-46.6333, -23.5505},
+		},
+	}
+}
+
+func validCreateBody(slug string, userId string) gin.H {
+	return gin.H{
+		"slug":   slug,
+		"link":   "https://example.com/landing",
+		"lat":    -23.5505,
+		"long":   -46.6333,
+		"userId": userId,
+	}
+}
+
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var value T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &value); err != nil {
+		t.Fatalf("decoding response %q: %v", recorder.Body.String(), err)
+	}
+
+	return value
+}
+
+func TestAccessQRCodeRecordsScan(t *testing.T) {
+	env := newTestEnv(t, nil, seededQRCode("promo", ownerId))
+
+	recorder := env.do("GET", "/promo", nil, map[string]string{
+		"X-User-Latitude":  "-23.56",
+		"X-User-Longitude": "-46.64",
+	})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	if got := decode[models.QRCode](t, recorder); got.Slug != "promo" {
+		t.Errorf("slug = %q, want promo", got.Slug)
+	}
+
+	scans := env.scans.All()
+	if len(scans) != 1 {
+		t.Fatalf("recorded %d scans, want 1", len(scans))
+	}
+
+	if coordinates := scans[0].Location.Coordinates; coordinates[0] != -46.64 || coordinates[1] != -23.56 {
+		t.Errorf("scan coordinates = %v, want [long, lat]", coordinates)
+	}
+}
+
+func TestAccessQRCodeMarksBots(t *testing.T) {
+	env := newTestEnv(t, nil, seededQRCode("promo", ownerId))
+
+	userAgents := map[string]bool{
+		browserUserAgent: false,
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)": true,
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":                true,
+		"WhatsApp/2.23.20.0": true,
+		"curl/8.4.0":         true,
+	}
+
+	for userAgent, wantBot := range userAgents {
+		recorder := env.do("GET", "/promo", nil, map[string]string{"User-Agent": userAgent})
+
+		// Bots continuam recebendo o destino do QR Code
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", userAgent, recorder.Code)
+		}
+
+		scans := env.scans.All()
+		if got := scans[len(scans)-1].IsBot; got != wantBot {
+			t.Errorf("%s: isBot = %v, want %v", userAgent, got, wantBot)
+		}
+	}
+}
+
+func TestAccessQRCodeUnknownSlug(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	recorder := env.do("GET", "/missing", nil, nil)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", recorder.Code)
+	}
+
+	problem := decode[apperror.Problem](t, recorder)
+
+	if problem.Status != 404 || problem.Detail != "QR Code não encontrado." || problem.Instance != "/missing" {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	if len(env.scans.All()) != 0 {
+		t.Error("scan recorded for unknown slug")
+	}
+}
+
+func TestCreateQRCode(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId})
+
+	recorder := env.do("POST", "/qr/", validCreateBody("launch", ownerId), nil)
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", recorder.Code, recorder.Body)
+	}
+
+	created := decode[QRCodeWithURL](t, recorder)
+
+	if created.Url != "https://qr.example.com/qr/launch" {
+		t.Errorf("url = %q", created.Url)
+	}
+
+	wantImage := created.ID.Hex() + ".png"
+	if created.ImageUrl != "/images/"+wantImage {
+		t.Errorf("image url = %q, want /images/%s", created.ImageUrl, wantImage)
+	}
+
+	if images := env.storedImages(t); len(images) != 1 || images[0] != wantImage {
+		t.Errorf("stored images = %v, want [%s]", images, wantImage)
+	}
+
+	stored, err := env.qrCodes.FindBySlug(t.Context(), "launch")
+	if err != nil {
+		t.Fatalf("qr code not persisted: %v", err)
+	}
+
+	if stored.Location.Coordinates[0] != -46.6333 || stored.Location.Coordinates[1] != -23.5505 {
+		t.Errorf("location = %v, want [long, lat]", stored.Location.Coordinates)
+	}
+}
+
+func TestCreateQRCodeInvalidBody(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId})
+
+	body := validCreateBody("launch", ownerId)
+	body["link"] = "not a url"
+	body["slug"] = "x"
+
+	recorder := env.do("POST", "/qr/", body, nil)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != apperror.ProblemContentType {
+		t.Errorf("content type = %q, want %q", contentType, apperror.ProblemContentType)
+	}
+
+	problem := decode[apperror.Problem](t, recorder)
+
+	rules := map[string]string{}
+	for _, field := range problem.Errors {
+		rules[field.Field] = field.Rule
+	}
+
+	if len(rules) != 2 || rules["slug"] != "min" || rules["link"] != "url" {
+		t.Errorf("field errors = %+v, want slug/min and link/url", problem.Errors)
+	}
+}
+
+func TestCreateQRCodeInvalidBodyIsLocalized(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId})
+
+	body := validCreateBody("launch", ownerId)
+	delete(body, "userId")
+
+	cases := []struct {
+		acceptLanguage string
+		detail         string
+		field          string
+	}{
+		{"", "Corpo da requisição inválido.", "userId é um campo obrigatório"},
+		{"en-US,en;q=0.9", "Invalid request body.", "userId is a required field"},
+		{"fr", "Corpo da requisição inválido.", "userId é um campo obrigatório"},
+	}
+
+	for _, tc := range cases {
+		recorder := env.do("POST", "/qr/", body, map[string]string{"Accept-Language": tc.acceptLanguage})
+		problem := decode[apperror.Problem](t, recorder)
+
+		if problem.Code != "request.invalid_body" || problem.Detail != tc.detail {
+			t.Errorf("%q: code = %q, detail = %q, want %q", tc.acceptLanguage, problem.Code, problem.Detail, tc.detail)
+		}
+
+		if len(problem.Errors) != 1 || problem.Errors[0].Message != tc.field {
+			t.Errorf("%q: field errors = %+v, want %q", tc.acceptLanguage, problem.Errors, tc.field)
+		}
+	}
+}
+
+func TestCreateQRCodeForAnotherUserIsForbidden(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: strangerId})
+
+	recorder := env.do("POST", "/qr/", validCreateBody("launch", ownerId), nil)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", recorder.Code)
+	}
+
+	if images := env.storedImages(t); len(images) != 0 {
+		t.Errorf("stored images = %v, want none", images)
+	}
+}
+
+func TestCreateQRCodeAsAdminForAnotherUser(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: strangerId, Scopes: []string{auth.ScopeAdmin}})
+
+	recorder := env.do("POST", "/qr/", validCreateBody("launch", ownerId), nil)
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestCreateQRCodeForDeletedUserFails(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: deletedId})
+
+	recorder := env.do("POST", "/qr/", validCreateBody("launch", deletedId), nil)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", recorder.Code)
+	}
+
+	if _, err := env.qrCodes.FindBySlug(t.Context(), "launch"); err != repositories.ErrNotFound {
+		t.Errorf("qr code persisted for deleted user")
+	}
+}
+
+func TestCreateQRCodeDuplicateSlugRemovesImage(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId}, seededQRCode("launch", ownerId))
+
+	recorder := env.do("POST", "/qr/", validCreateBody("launch", ownerId), nil)
+
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", recorder.Code)
+	}
+
+	if images := env.storedImages(t); len(images) != 0 {
+		t.Errorf("orphan images left behind: %v", images)
+	}
+}
+
+func TestFindAllQRCodesOnlyForOwner(t *testing.T) {
+	qrCodes := []models.QRCode{
+		seededQRCode("mine", ownerId),
+		seededQRCode("theirs", strangerId),
+	}
+
+	t.Run("owner", func(t *testing.T) {
+		env := newTestEnv(t, &auth.Principal{UserId: ownerId}, qrCodes...)
+
+		recorder := env.do("GET", "/qr/user/"+ownerId, nil, nil)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", recorder.Code)
+		}
+
+		list := decode[[]QRCodeWithURL](t, recorder)
+		if len(list) != 1 || list[0].Slug != "mine" {
+			t.Errorf("listed %+v, want only 'mine'", list)
+		}
+	})
+
+	t.Run("stranger", func(t *testing.T) {
+		env := newTestEnv(t, &auth.Principal{UserId: strangerId}, qrCodes...)
+
+		recorder := env.do("GET", "/qr/user/"+ownerId, nil, nil)
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", recorder.Code)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		env := newTestEnv(t, nil, qrCodes...)
+
+		recorder := env.do("GET", "/qr/user/"+ownerId, nil, nil)
+
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", recorder.Code)
+		}
+	})
+}
+
+func TestFindNearScans(t *testing.T) {
+	qrCode := seededQRCode("promo", ownerId)
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId}, qrCode)
+
+	// ~1.1 km e ~11 km ao norte do QR Code
+	for _, latitude := range []string{"-23.5405", "-23.4505"} {
+		env.do("GET", "/promo", nil, map[string]string{
+			"X-User-Latitude":  latitude,
+			"X-User-Longitude": "-46.6333",
+		})
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantScans int
+	}{
+		{"default radius", "", http.StatusOK, 1},
+		{"wider radius", "?maxDistance=20000", http.StatusOK, 2},
+		{"invalid radius", "?maxDistance=far", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := env.do("GET", "/qr/near/promo"+tt.query, nil, nil)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			scans := decode[[]models.Scan](t, recorder)
+			if len(scans) != tt.wantScans {
+				t.Errorf("got %d scans, want %d", len(scans), tt.wantScans)
+			}
+		})
+	}
+}
+
+func TestFindNearScansExcludesBots(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId}, seededQRCode("promo", ownerId))
+
+	nearby := map[string]string{"X-User-Latitude": "-23.5505", "X-User-Longitude": "-46.6333"}
+	env.do("GET", "/promo", nil, nearby)
+
+	nearby["User-Agent"] = "Twitterbot/1.0"
+	env.do("GET", "/promo", nil, nearby)
+
+	scans := decode[[]models.Scan](t, env.do("GET", "/qr/near/promo", nil, nil))
+	if len(scans) != 1 || scans[0].IsBot {
+		t.Errorf("got %+v, want only the human scan", scans)
+	}
+}
+
+func TestFindScanStats(t *testing.T) {
+	qrCode := seededQRCode("promo", ownerId)
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId}, qrCode)
+
+	day := func(date string, hour int) time.Time {
+		parsed, _ := time.Parse(time.DateOnly, date)
+		return parsed.Add(time.Duration(hour) * time.Hour)
+	}
+
+	for _, seeded := range []models.Scan{
+		{QRCodeId: qrCode.ID, ScanedAt: day("2026-03-01", 9)},
+		{QRCodeId: qrCode.ID, ScanedAt: day("2026-03-01", 23)},
+		{QRCodeId: qrCode.ID, ScanedAt: day("2026-03-02", 10), IsBot: true},
+		{QRCodeId: qrCode.ID, ScanedAt: day("2026-03-03", 8)},
+		{QRCodeId: qrCode.ID, ScanedAt: day("2026-03-04", 8)},
+	} {
+		env.scans.Insert(t.Context(), seeded)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantTotal int64
+		wantDays  int
+	}{
+		{"humans only", "?from=2026-03-01&to=2026-03-03", http.StatusOK, 3, 2},
+		{"including bots", "?from=2026-03-01&to=2026-03-03&includeBots=true", http.StatusOK, 4, 3},
+		{"single day", "?from=2026-03-04&to=2026-03-04", http.StatusOK, 1, 1},
+		{"invalid date", "?from=01/03/2026", http.StatusBadRequest, 0, 0},
+		{"inverted range", "?from=2026-03-04&to=2026-03-01", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := env.do("GET", "/qr/stats/promo"+tt.query, nil, nil)
+
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantCode, recorder.Body)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			stats := decode[scan.ScanStats](t, recorder)
+			if stats.Total != tt.wantTotal || len(stats.Days) != tt.wantDays {
+				t.Errorf("total = %d, days = %d, want %d and %d", stats.Total, len(stats.Days), tt.wantTotal, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestFindScanStatsCountsUniqueVisitors(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId}, seededQRCode("promo", ownerId))
+
+	// O mesmo visitante três vezes e um segundo visitante com outro navegador
+	for range 3 {
+		env.do("GET", "/promo", nil, nil)
+	}
+	env.do("GET", "/promo", nil, map[string]string{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"})
+
+	for _, recorded := range env.scans.All() {
+		if len(recorded.VisitorId) != 32 {
+			t.Fatalf("visitorId = %q, want a 128-bit hex hash", recorded.VisitorId)
+		}
+	}
+
+	stats := decode[scan.ScanStats](t, env.do("GET", "/qr/stats/promo", nil, nil))
+
+	if stats.Total != 4 || stats.Unique != 2 {
+		t.Errorf("total = %d, unique = %d, want 4 and 2", stats.Total, stats.Unique)
+	}
+}
+
+func TestFindNearScansForbiddenForStranger(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: strangerId}, seededQRCode("promo", ownerId))
+
+	recorder := env.do("GET", "/qr/near/promo", nil, nil)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", recorder.Code)
+	}
+}
+
+func TestAccessQRCodeServesFromCache(t *testing.T) {
+	qrCode := seededQRCode("promo", ownerId)
+	env := newTestEnv(t, nil, qrCode)
+
+	env.do("GET", "/promo", nil, nil)
+
+	// Alterado direto no banco, sem que a invalidação do change stream chegue
+	env.qrCodes.SetImageKey(t.Context(), qrCode.ID, "changed.png")
+
+	recorder := env.do("GET", "/promo", nil, nil)
+
+	if got := decode[models.QRCode](t, recorder); got.ImageKey != qrCode.ImageKey {
+		t.Errorf("image key = %q, want the cached %q", got.ImageKey, qrCode.ImageKey)
+	}
+
+	if stats := env.service.Cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("cache stats = %+v, want 1 hit and 1 miss", stats)
+	}
+
+	if len(env.scans.All()) != 2 {
+		t.Error("cached accesses must still record scans")
+	}
+}
+
+func TestCreateQRCodeClearsNegativeCache(t *testing.T) {
+	env := newTestEnv(t, &auth.Principal{UserId: ownerId})
+
+	env.do("GET", "/launch", nil, nil)
+	env.do("POST", "/qr/", validCreateBody("launch", ownerId), nil)
+
+	if recorder := env.do("GET", "/launch", nil, nil); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 once the slug exists", recorder.Code)
+	}
+}
+
+func TestAccessQRCodeCountsRedirects(t *testing.T) {
+	env := newTestEnv(t, nil, seededQRCode("promo", ownerId))
+
+	found := testutil.ToFloat64(metrics.Redirects.WithLabelValues("200"))
+	missing := testutil.ToFloat64(metrics.Redirects.WithLabelValues("404"))
+
+	env.do("GET", "/promo", nil, nil)
+	env.do("GET", "/missing", nil, nil)
+
+	if got := testutil.ToFloat64(metrics.Redirects.WithLabelValues("200")) - found; got != 1 {
+		t.Errorf("redirects with 200 = %v, want 1", got)
+	}
+
+	if got := testutil.ToFloat64(metrics.Redirects.WithLabelValues("404")) - missing; got != 1 {
+		t.Errorf("redirects with 404 = %v, want 1", got)
+	}
+}
@@ -0,0 +1,131 @@
+package qrcode
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"qr-code-boost/src/logging"
+	"time"
+
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+)
+
+type ReconcileOptions struct {
+	// Imagens mais novas que GracePeriod são ignoradas, pois podem pertencer
+	// a uma criação que ainda não inseriu o documento.
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+type ReconcileReport struct {
+	OrphanImages      []string `json:"orphanImages"`
+	MissingImages     []string `json:"missingImages"`
+	DeletedImages     int      `json:"deletedImages"`
+	RegeneratedImages int      `json:"regeneratedImages"`
+}
+
+// Reconcile remove imagens sem documento e regenera as imagens de documentos
+// que perderam o arquivo (ou que nunca tiveram um).
+func (s *QRCodeService) Reconcile(ctx context.Context, opts ReconcileOptions) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	objects, err := s.Storage.List(ctx)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao listar imagens do storage", logging.Err(err))
+		return report, err
+	}
+
+	storedKeys := make(map[string]time.Time, len(objects))
+	for _, object := range objects {
+		storedKeys[object.Key] = object.LastModified
+	}
+
+	qrCodes, err := s.QRCodes.FindAll(ctx, repositories.QRCodeFilter{})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao buscar QR Codes na collection", logging.Err(err))
+		return report, err
+	}
+
+	referencedKeys := make(map[string]bool)
+
+	for _, qrCode := range qrCodes {
+		if qrCode.ImageKey != "" {
+			referencedKeys[qrCode.ImageKey] = true
+
+			if _, ok := storedKeys[qrCode.ImageKey]; ok {
+				continue
+			}
+		}
+
+		report.MissingImages = append(report.MissingImages, qrCode.Slug)
+
+		if opts.DryRun {
+			continue
+		}
+
+		imageKey, err := s.regenerateImage(ctx, qrCode)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Erro ao regenerar imagem do QR Code", logging.Slug(qrCode.Slug), logging.QRCodeId(qrCode.ID), logging.Err(err))
+			return report, err
+		}
+
+		referencedKeys[imageKey] = true
+		report.RegeneratedImages++
+	}
+
+	threshold := time.Now().Add(-opts.GracePeriod)
+
+	for key, lastModified := range storedKeys {
+		if referencedKeys[key] || lastModified.After(threshold) {
+			continue
+		}
+
+		report.OrphanImages = append(report.OrphanImages, key)
+
+		if opts.DryRun {
+			continue
+		}
+
+		if err := s.Storage.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Erro ao remover imagem órfã", "imageKey", key, logging.Err(err))
+			return report, err
+		}
+
+		report.DeletedImages++
+	}
+
+	return report, nil
+}
+
+func (s *QRCodeService) regenerateImage(ctx context.Context, qrCode models.QRCode) (string, error) {
+	buffer, err := generateQRCode(qrCode.Link)
+
+	if err != nil {
+		return "", err
+	}
+
+	imageKey := qrCode.ImageKey
+	if imageKey == "" {
+		imageKey = fmt.Sprintf("%s.png", qrCode.ID.Hex())
+	}
+
+	err = s.Storage.Save(ctx, imageKey, buffer, "image/png")
+
+	if err != nil {
+		return "", err
+	}
+
+	if qrCode.ImageKey == "" {
+		err = s.QRCodes.SetImageKey(ctx, qrCode.ID, imageKey)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return imageKey, nil
+}
@@ -2,18 +2,28 @@ package qrcode
 
 import (
 	"qr-code-boost/src/middlewares"
+	"qr-code-boost/src/workspace"
 
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary      QR Code Routes
-func QRCodesRouter(r *gin.Engine, qrCodeController *QRCodeController) {
-	r.GET("/:slug", qrCodeController.AccessQRCode)
+// accessGuards protegem a rota pública de acesso; guards, o grupo /qr.
+func QRCodesRouter(r *gin.Engine, qrCodeController *QRCodeController, accessGuards []gin.HandlerFunc, guards ...gin.HandlerFunc) {
+	db := qrCodeController.PostgresClient
 
-	qrCodeRoutes := r.Group("/qr", middlewares.InternalOnlyMiddleware())
+	r.GET("/:slug", append(accessGuards, qrCodeController.AccessQRCode)...)
+
+	qrCodeRoutes := r.Group("/qr", guards...)
 	{
-		qrCodeRoutes.POST("/", qrCodeController.CreateQRCode)
-		qrCodeRoutes.GET("/near/:slug", qrCodeController.FindNearScans)
+		qrCodeRoutes.POST("/",
+			workspace.RequirePermission(db, workspace.PermissionQRCodeWrite),
+			middlewares.IdempotencyMiddleware(qrCodeController.MongoDatabase),
+			qrCodeController.CreateQRCode,
+		)
+		qrCodeRoutes.GET("/near/:slug", workspace.RequirePermission(db, workspace.PermissionStatsRead), qrCodeController.FindNearScans)
+		qrCodeRoutes.GET("/stats/:slug", workspace.RequirePermission(db, workspace.PermissionStatsRead), qrCodeController.FindScanStats)
 		qrCodeRoutes.GET("/user/:userId", qrCodeController.FindAllQRCodes)
+		qrCodeRoutes.GET("/workspace/:workspaceId", workspace.RequirePermission(db, workspace.PermissionQRCodeRead), qrCodeController.FindAllWorkspaceQRCodes)
 	}
 }
@@ -2,67 +2,103 @@ package qrcode
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/user"
 	"time"
 
 	"qr-code-boost/src/config"
-	"qr-code-boost/src/user"
+	"qr-code-boost/src/repositories"
+	"qr-code-boost/src/storage"
 
 	"qr-code-boost/src/mongo/models"
 
 	"qr-code-boost/src/scan"
 
 	qrcode "github.com/skip2/go-qrcode"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrQRCodeNotFound = apperror.New(apperror.NotFound, "qrcode.not_found")
+	ErrSlugTaken      = apperror.New(apperror.Conflict, "qrcode.slug_taken")
+	ErrUserDeleted    = apperror.New(apperror.Forbidden, "qrcode.user_deleted")
 )
 
 type QRCodeWithURL struct {
 	models.QRCode
-	Url string `bson:"url"`
+	Url      string `bson:"url"`
+	ImageUrl string `bson:"imageUrl"`
 }
 
-func Create(dto CreateQRCodeDto, mongoClient *mongo.Client, postgresClient *sql.DB) (QRCodeWithURL, error) {
-	webURL, envErr := config.GetEnvVariable("WEB_URL")
+type QRCodeService struct {
+	QRCodes      repositories.QRCodeRepository
+	Users        repositories.UserRepository
+	Scans        *scan.ScanService
+	Storage      storage.Storage
+	Cache        *SlugCache
+	WebURL       string
+	QueryTimeout time.Duration
+
+	// Junta as buscas simultâneas pelo mesmo slug fora do cache, como no
+	// primeiro minuto de um evento em que todos escaneiam o mesmo QR Code.
+	lookups singleflight.Group
+}
 
-	if envErr != nil {
-		return QRCodeWithURL{}, envErr
+func NewQRCodeService(qrCodes repositories.QRCodeRepository, users repositories.UserRepository, scans *scan.ScanService, imageStorage storage.Storage, cfg *config.Config) *QRCodeService {
+	return &QRCodeService{
+		QRCodes:      qrCodes,
+		Users:        users,
+		Scans:        scans,
+		Storage:      imageStorage,
+		Cache:        NewSlugCache(cfg.SlugCache),
+		WebURL:       cfg.WebURL,
+		QueryTimeout: cfg.QueryTimeout,
 	}
+}
+
+func (s *QRCodeService) Create(ctx context.Context, dto CreateQRCodeDto) (QRCodeWithURL, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.QueryTimeout)
+	defer cancel() // QUANDO TERMINAR DE UTILIZAR O CONTEXTO A CONEXÃO É FECHADA
 
-	user, err := user.FindById(dto.UserId, postgresClient)
+	owner, err := s.Users.FindById(ctx, dto.UserId)
 
 	if err != nil {
-		fmt.Println("Erro ao buscar usuário.")
+		slog.ErrorContext(ctx, "Erro ao buscar usuário", logging.UserId(dto.UserId), logging.Err(err))
 		return QRCodeWithURL{}, err
 	}
 
-	if user == nil || user.Name == "" {
-		fmt.Println("Usuário não encontrado.")
-		return QRCodeWithURL{}, errors.New("user not found")
+	if owner == nil {
+		slog.InfoContext(ctx, "Usuário não encontrado", logging.UserId(dto.UserId))
+		return QRCodeWithURL{}, user.ErrUserNotFound
 	}
 
-	collection := mongoClient.Database("qr-code-boost").Collection("qrcodes")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel() // QUANDO TERMINAR DE UTILIZAR O CONTEXTO A CONEXÃO É FECHADA
+	if owner.IsDeleted() {
+		slog.InfoContext(ctx, "Usuário removido não pode criar QR Codes", logging.UserId(dto.UserId))
+		return QRCodeWithURL{}, ErrUserDeleted
+	}
 
 	buffer, err := generateQRCode(dto.Link)
 
 	if err != nil {
-		fmt.Printf("Error writing file: %v", err)
-		return QRCodeWithURL{}, nil
+		slog.ErrorContext(ctx, "Erro ao gerar imagem do QR Code", logging.UserId(dto.UserId), logging.Err(err))
+		return QRCodeWithURL{}, err
 	}
 
 	id := primitive.NewObjectID()
+	imageKey := fmt.Sprintf("%s.png", id.Hex())
 
-	filename := fmt.Sprintf("%s.png", id.Hex())
-	filepath := fmt.Sprintf("./static/images/%s", filename)
-	errSavingFile := saveStaticFile(buffer, filepath)
+	// A imagem é salva antes do documento: se a inserção falhar ela é removida,
+	// e se sobrar algum arquivo órfão o comando reconcile o encontra.
+	err = s.Storage.Save(ctx, imageKey, buffer, "image/png")
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao salvar imagem no storage", "imageKey", imageKey, logging.Err(err))
+		return QRCodeWithURL{}, err
+	}
 
 	qrCode := models.QRCode{
 		ID:   id,
@@ -72,32 +108,57 @@ func Create(dto CreateQRCodeDto, mongoClient *mongo.Client, postgresClient *sql.
 			Type:        "Point",
 			Coordinates: []float64{dto.Long, dto.Lat},
 		},
-		UserId:    dto.UserId,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserId:      dto.UserId,
+		WorkspaceId: dto.WorkspaceId,
+		ImageKey:    imageKey,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 
-	_, errCreating := collection.InsertOne(ctx, qrCode)
+	errCreating := s.QRCodes.Insert(ctx, qrCode)
 
 	if errCreating != nil {
-		fmt.Println("Erro ao inserir QR Code na collection.")
+		slog.ErrorContext(ctx, "Erro ao inserir QR Code", logging.Slug(qrCode.Slug), logging.UserId(qrCode.UserId), logging.Err(errCreating))
+
+		// A limpeza não depende de o cliente ainda estar esperando a resposta
+		errDeleting := s.Storage.Delete(context.WithoutCancel(ctx), imageKey)
+		if errDeleting != nil {
+			slog.ErrorContext(ctx, "Erro ao remover imagem", logging.QRCodeId(id), "imageKey", imageKey, logging.Err(errDeleting))
+		}
+
+		if errCreating == repositories.ErrDuplicate {
+			return QRCodeWithURL{}, ErrSlugTaken
+		}
+
 		return QRCodeWithURL{}, errCreating
 	}
 
-	if errSavingFile != nil {
-		fmt.Printf("Erro ao salvar arquivo estático: %v", errSavingFile)
-		collection.DeleteOne(context.Background(), bson.M{"_id": id})
-		return QRCodeWithURL{}, nil
-	}
+	// Remove uma entrada negativa de quem tentou acessar o slug antes de existir
+	s.Cache.Invalidate(qrCode.Slug)
 
-	fmt.Printf("Código QR criado com sucesso! ID: %s\n", id.Hex())
+	slog.InfoContext(ctx, "QR Code criado", logging.QRCodeId(id), logging.Slug(qrCode.Slug), logging.UserId(qrCode.UserId))
 
-	qrCodeCreated := QRCodeWithURL{
+	return s.withURLs(ctx, qrCode)
+}
+
+func (s *QRCodeService) withURLs(ctx context.Context, qrCode models.QRCode) (QRCodeWithURL, error) {
+	qrCodeWithURL := QRCodeWithURL{
 		QRCode: qrCode,
-		Url:    webURL + "/qr/" + dto.Slug,
+		Url:    s.WebURL + "/qr/" + qrCode.Slug,
+	}
+
+	if qrCode.ImageKey != "" {
+		imageURL, err := s.Storage.URL(ctx, qrCode.ImageKey)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Erro ao gerar URL da imagem", logging.QRCodeId(qrCode.ID), logging.Err(err))
+			return QRCodeWithURL{}, err
+		}
+
+		qrCodeWithURL.ImageUrl = imageURL
 	}
 
-	return qrCodeCreated, nil
+	return qrCodeWithURL, nil
 }
 
 func generateQRCode(link string) ([]byte, error) {
@@ -111,136 +172,150 @@ func generateQRCode(link string) ([]byte, error) {
 	return buffer, nil
 }
 
-func saveStaticFile(buffer []byte, filepath string) error {
-	errSavingFile := os.WriteFile(filepath, buffer, 0644)
-	return errSavingFile
+func (s *QRCodeService) FindAll(ctx context.Context, userId string) ([]QRCodeWithURL, error) {
+	return s.findWithURLs(ctx, repositories.QRCodeFilter{UserId: userId})
 }
 
-func FindAll(userId string, client *mongo.Client) ([]QRCodeWithURL, error) {
-	webURL, err := config.GetEnvVariable("WEB_URL")
-
-	if err != nil {
-		return nil, err
-	}
-
-	collection := client.Database("qr-code-boost").Collection("qrcodes")
+func (s *QRCodeService) FindAllByWorkspace(ctx context.Context, workspaceId string) ([]QRCodeWithURL, error) {
+	return s.findWithURLs(ctx, repositories.QRCodeFilter{WorkspaceId: workspaceId})
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *QRCodeService) findWithURLs(ctx context.Context, filter repositories.QRCodeFilter) ([]QRCodeWithURL, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.QueryTimeout)
 	defer cancel() // QUANDO TERMINAR DE UTILIZAR O CONTEXTO A CONEXÃO É FECHADA
 
-	filter := bson.D{{Key: "userId", Value: userId}}
-
-	cursor, err := collection.Find(ctx, filter)
+	qrCodes, err := s.QRCodes.FindAll(ctx, filter)
 
 	if err != nil {
-		fmt.Println("Erro ao buscar QR Codes na collection.")
+		slog.ErrorContext(ctx, "Erro ao buscar QR Codes", logging.UserId(filter.UserId), logging.WorkspaceId(filter.WorkspaceId), logging.Err(err))
 		return nil, err
 	}
 
-	defer cursor.Close(ctx)
-
-	var qrCodesWithURL []QRCodeWithURL
-
-	for cursor.Next(ctx) {
-		log.Println("Documento encontrado...")
+	qrCodesWithURL := make([]QRCodeWithURL, 0, len(qrCodes))
 
-		var qrCode models.QRCode
-		var qrCodeWithURL QRCodeWithURL
-
-		err := cursor.Decode(&qrCode)
+	for _, qrCode := range qrCodes {
+		qrCodeWithURL, err := s.withURLs(ctx, qrCode)
 
 		if err != nil {
-			fmt.Printf("Erro ao buscando QR Codes na collection: %v", err)
 			return nil, err
 		}
 
-		qrCodeWithURL.QRCode = qrCode
-
-		qrCodeWithURL.Url = webURL + "/qr/" + qrCode.Slug
-
 		qrCodesWithURL = append(qrCodesWithURL, qrCodeWithURL)
 	}
 
 	return qrCodesWithURL, nil
 }
 
-func FindById(qrCodeId string, client *mongo.Client) (models.QRCode, error) {
-	coll := client.Database("qr-code-boost").Collection("qrcodes")
+// AccessQRCode registra o scan mesmo para bots, que continuam sendo
+// redirecionados, mas marcados para ficar fora das estatísticas.
+func (s *QRCodeService) AccessQRCode(ctx context.Context, slug string, dto AccessQRCodeDto) (models.QRCode, error) {
+	qrCode, err := s.findCachedBySlug(ctx, slug)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			slog.InfoContext(ctx, "QR Code não encontrado no acesso", logging.Slug(slug))
+			return models.QRCode{}, ErrQRCodeNotFound
+		}
 
-	filter := bson.D{{Key: "_id", Value: qrCodeId}}
+		slog.ErrorContext(ctx, "Erro ao buscar QR Code no acesso", logging.Slug(slug), logging.Err(err))
+		return models.QRCode{}, err
+	}
 
-	var result models.QRCode
-	err := coll.FindOne(context.TODO(), filter).Decode(&result)
+	_, err = s.Scans.Create(ctx, scan.CreateScanDto{
+		QRCodeId:  qrCode.ID,
+		Lat:       dto.Lat,
+		Long:      dto.Long,
+		IsBot:     dto.IsBot,
+		ClientIP:  dto.ClientIP,
+		UserAgent: dto.UserAgent,
+	})
 
+	// Perder um scan não deve impedir o redirecionamento de quem escaneou
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return models.QRCode{}, err
-		}
-		panic(err)
+		slog.ErrorContext(ctx, "Erro ao registrar scan", logging.Slug(slug), logging.QRCodeId(qrCode.ID), logging.Err(err))
 	}
 
-	return result, nil
+	return qrCode, nil
 }
 
-func AccessQRCode(slug string, dto CoordinatesDto, client *mongo.Client) (models.QRCode, error) {
-	qrCode, err := FindBySlug(slug, client)
-	if err != nil {
-		fmt.Printf("\n\n [QRCODE SERVICE AccessQRCode] Erro ao encontrar QR Code: %v\n\n", err)
-		return models.QRCode{}, err
+// findCachedBySlug atende o redirecionamento pelo SlugCache, guardando também
+// os slugs inexistentes.
+func (s *QRCodeService) findCachedBySlug(ctx context.Context, slug string) (models.QRCode, error) {
+	if cached, ok := s.Cache.Get(slug); ok {
+		if cached.NotFound {
+			return models.QRCode{}, repositories.ErrNotFound
+		}
+		return cached.QRCode, nil
 	}
 
-	fmt.Printf("QR Code encontrado: %s\n", qrCode.Slug)
-	_, err = scan.Create(scan.CreateScanDto{
-		QRCodeId: qrCode.ID,
-		Lat:      dto.Lat,
-		Long:     dto.Long,
-	}, client)
+	result, err, _ := s.lookups.Do(slug, func() (any, error) {
+		generation := s.Cache.Generation()
+
+		// A busca é compartilhada com as outras requisições pelo mesmo slug, então
+		// não é cancelada se o primeiro cliente desistir; o trace continua o dele.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.QueryTimeout)
+		defer cancel() // QUANDO TERMINAR DE UTILIZAR O CONTEXTO A CONEXÃO É FECHADA
+
+		qrCode, err := s.QRCodes.FindBySlug(ctx, slug)
+
+		switch {
+		case err == repositories.ErrNotFound:
+			s.Cache.SetNotFoundSince(slug, generation)
+		case err == nil:
+			s.Cache.SetSince(qrCode, generation)
+		}
+
+		return qrCode, err
+	})
 
 	if err != nil {
-		fmt.Printf("\n\n [QRCODE SERVICE AccessQRCode] Erro ao criar scan: %v\n\n", err)
 		return models.QRCode{}, err
 	}
 
-	return qrCode, nil
+	return result.(models.QRCode), nil
 }
 
-func FindBySlug(slug string, client *mongo.Client) (models.QRCode, error) {
-	coll := client.Database("qr-code-boost").Collection("qrcodes")
-
-	filter := bson.D{{Key: "slug", Value: slug}}
+func (s *QRCodeService) FindBySlug(ctx context.Context, slug string) (models.QRCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.QueryTimeout)
+	defer cancel() // QUANDO TERMINAR DE UTILIZAR O CONTEXTO A CONEXÃO É FECHADA
 
-	var result models.QRCode
-	err := coll.FindOne(context.TODO(), filter).Decode(&result)
+	result, err := s.QRCodes.FindBySlug(ctx, slug)
 
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			fmt.Printf("\n\n [QRCODE SERVICE FindBySlug] QR Code não encontrado: %s\n\n", slug)
-			return models.QRCode{}, err
+		if err == repositories.ErrNotFound {
+			slog.InfoContext(ctx, "QR Code não encontrado", logging.Slug(slug))
+			return models.QRCode{}, ErrQRCodeNotFound
 		}
-		panic(err)
+
+		slog.ErrorContext(ctx, "Erro ao buscar QR Code", logging.Slug(slug), logging.Err(err))
+		return models.QRCode{}, err
 	}
 
 	return result, nil
 }
 
-func FindNearScans(slug string, maxDistance int64, client *mongo.Client) ([]models.Scan, error) {
-	qrCode, err := FindBySlug(slug, client)
+// FindNearScans usa o raio padrão configurado quando maxDistance é nil.
+func (s *QRCodeService) FindNearScans(ctx context.Context, qrCode models.QRCode, maxDistance *int64) ([]models.Scan, error) {
+	findNearScansFilterDto := scan.FindNearScansFilterDto{
+		MaxDistance: maxDistance,
+	}
+
+	scans, err := s.Scans.FindNearScans(ctx, findNearScansFilterDto, qrCode)
 
 	if err != nil {
-		fmt.Printf("\n\n [QRCODE SERVICE FindNearScans] Erro ao encontrar QR Code: %v\n\n", err)
+		slog.ErrorContext(ctx, "Erro ao buscar scans próximos", logging.Slug(qrCode.Slug), logging.QRCodeId(qrCode.ID), logging.Err(err))
 		return nil, err
 	}
 
-	findNearScansFilterDto := scan.FindNearScansFilterDto{
-		MaxDistance: &maxDistance,
-	}
+	return scans, nil
+}
 
-	scans, err := scan.FindNearScans(findNearScansFilterDto, qrCode, client)
+func (s *QRCodeService) FindScanStats(ctx context.Context, qrCode models.QRCode, filter repositories.ScanStatsFilter) (scan.ScanStats, error) {
+	stats, err := s.Scans.FindStats(ctx, qrCode, filter)
 
 	if err != nil {
-		fmt.Printf("\n\n [QRCODE SERVICE FindNearScans] Erro ao buscar scans próximos: %v\n\n", err)
-		return nil, err
+		slog.ErrorContext(ctx, "Erro ao buscar estatísticas", logging.Slug(qrCode.Slug), logging.QRCodeId(qrCode.ID), logging.Err(err))
+		return scan.ScanStats{}, err
 	}
 
-	return scans, nil
+	return stats, nil
 }
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Limit: 2, Period: 10 * time.Second}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	take := func(at time.Time) Result {
+		t.Helper()
+		result, err := store.Take(context.Background(), "client", policy, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := take(now); !result.Allowed || result.Remaining != 1 {
+		t.Fatalf("first request: %+v", result)
+	}
+	if result := take(now); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("second request: %+v", result)
+	}
+
+	result := take(now)
+	if result.Allowed || result.RetryAfter != 5*time.Second || result.ResetAfter != 10*time.Second {
+		t.Fatalf("third request should be limited: %+v", result)
+	}
+
+	// Uma ficha é reposta a cada 5s
+	if result := take(now.Add(5 * time.Second)); !result.Allowed {
+		t.Fatalf("request after refill: %+v", result)
+	}
+
+	other, _ := store.Take(context.Background(), "other", policy, now)
+	if !other.Allowed {
+		t.Fatalf("buckets must be independent per key: %+v", other)
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy define um token bucket: até Limit requisições de uma vez, repostas
+// gradualmente ao longo de Period.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter é o tempo até a próxima ficha ficar disponível (zero quando
+	// a requisição foi permitida). ResetAfter é o tempo até o bucket encher.
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// Store guarda os buckets. Implementações devem consumir a ficha de forma
+// atômica, pois várias requisições da mesma chave chegam ao mesmo tempo.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// refill calcula quantas fichas existem em now, partindo de tokens em updated.
+func refill(policy Policy, tokens float64, updated time.Time, now time.Time) float64 {
+	elapsed := now.Sub(updated)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	tokens += float64(elapsed) / float64(policy.Period) * float64(policy.Limit)
+	return math.Min(tokens, float64(policy.Limit))
+}
+
+// newResult traduz o estado do bucket após a tentativa nos valores expostos
+// nos cabeçalhos RateLimit-*.
+func newResult(policy Policy, allowed bool, tokens float64) Result {
+	perToken := float64(policy.Period) / float64(policy.Limit)
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      policy.Limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(policy.Limit) - tokens) * perToken),
+	}
+
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+
+	return result
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisClient é o mínimo que o RedisStore precisa para rodar o script. O
+// *redis.Client e o *redis.ClusterClient já o implementam, e qualquer
+// servidor compatível com Redis (Valkey, KeyDB...) pode ser usado.
+type RedisClient interface {
+	redis.Scripter
+}
+
+// O bucket é lido e atualizado dentro do script para que réplicas diferentes
+// não consumam a mesma ficha. O horário vem da API, então os relógios das
+// réplicas precisam estar sincronizados.
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+
+if tokens == nil then
+	tokens = limit
+	updated = now
+end
+
+local elapsed = math.max(0, now - updated)
+tokens = math.min(limit, tokens + elapsed * limit / period)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], period)
+
+return {allowed, tostring(tokens)}
+`)
+
+type RedisStore struct {
+	Client RedisClient
+	Prefix string
+}
+
+func NewRedisStore(client RedisClient) *RedisStore {
+	return &RedisStore{Client: client, Prefix: "ratelimit:"}
+}
+
+// Take envia só o SHA do script (EVALSHA); o texto completo vai apenas
+// quando o Redis ainda não o conhece, como após um restart.
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.Client, []string{s.Prefix + key},
+		policy.Limit,
+		policy.Period.Milliseconds(),
+		now.UnixMilli(),
+	).Result()
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("resposta inesperada do redis: %v", reply)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensText, _ := values[1].(string)
+
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("resposta inesperada do redis: %v", reply)
+	}
+
+	return newResult(policy, allowed == 1, tokens), nil
+}
+
+// GoRedisClient é o cliente do go-redis criado a partir da REDIS_URL.
+type GoRedisClient struct {
+	redis.UniversalClient
+}
+
+func NewGoRedisClient(url string) (*GoRedisClient, error) {
+	options, err := redis.ParseURL(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL inválida: %v", err)
+	}
+
+	return &GoRedisClient{UniversalClient: redis.NewClient(options)}, nil
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis guarda os scripts carregados, como o Redis, e responde reply a
+// cada execução.
+type fakeRedis struct {
+	scripts map[string]bool
+	reply   any
+	err     error
+	calls   []string
+	keys    []string
+	args    []any
+}
+
+func newFakeRedis(reply any) *fakeRedis {
+	return &fakeRedis{scripts: map[string]bool{}, reply: reply}
+}
+
+func (f *fakeRedis) run(command string, keys []string, args []any) *redis.Cmd {
+	f.calls = append(f.calls, command)
+	f.keys = keys
+	f.args = args
+
+	return redis.NewCmdResult(f.reply, f.err)
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	sum := sha1.Sum([]byte(script))
+	f.scripts[hex.EncodeToString(sum[:])] = true
+
+	return f.run("EVAL", keys, args)
+}
+
+func (f *fakeRedis) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) *redis.Cmd {
+	if !f.scripts[sha] {
+		f.calls = append(f.calls, "EVALSHA")
+		return redis.NewCmdResult(nil, noScriptError{})
+	}
+
+	return f.run("EVALSHA", keys, args)
+}
+
+// noScriptError é a resposta do Redis para um EVALSHA de script desconhecido.
+type noScriptError struct{}
+
+func (noScriptError) Error() string { return "NOSCRIPT No matching script. Please use EVAL." }
+func (noScriptError) RedisError()   {}
+
+func (f *fakeRedis) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.Eval(ctx, script, keys, args...)
+}
+
+func (f *fakeRedis) EvalShaRO(ctx context.Context, sha string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, sha, keys, args...)
+}
+
+func (f *fakeRedis) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	exists := make([]bool, len(hashes))
+	for i, hash := range hashes {
+		exists[i] = f.scripts[hash]
+	}
+	return redis.NewBoolSliceResult(exists, nil)
+}
+
+func (f *fakeRedis) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	sum := sha1.Sum([]byte(script))
+	f.scripts[hex.EncodeToString(sum[:])] = true
+	return redis.NewStringResult(hex.EncodeToString(sum[:]), nil)
+}
+
+func TestRedisStoreUsesEvalSha(t *testing.T) {
+	client := newFakeRedis([]interface{}{int64(1), "4.5"})
+	store := NewRedisStore(client)
+	policy := Policy{Name: "api", Limit: 5, Period: time.Minute}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	result, err := store.Take(t.Context(), "api:key:1", policy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Allowed || result.Remaining != 4 || result.Limit != 5 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if len(client.keys) != 1 || client.keys[0] != "ratelimit:api:key:1" {
+		t.Errorf("keys = %v", client.keys)
+	}
+
+	if len(client.args) != 3 || client.args[0] != 5 || client.args[1] != int64(60000) || client.args[2] != now.UnixMilli() {
+		t.Errorf("args = %v", client.args)
+	}
+
+	// O script só é enviado por inteiro quando o Redis ainda não o conhece
+	store.Take(t.Context(), "api:key:1", policy, now)
+
+	want := []string{"EVALSHA", "EVAL", "EVALSHA"}
+	if len(client.calls) != len(want) || client.calls[0] != want[0] || client.calls[1] != want[1] || client.calls[2] != want[2] {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestRedisStoreLimited(t *testing.T) {
+	store := NewRedisStore(newFakeRedis([]interface{}{int64(0), "0.25"}))
+	policy := Policy{Name: "api", Limit: 4, Period: 4 * time.Second}
+
+	result, err := store.Take(t.Context(), "client", policy, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Faltam 0,75 ficha, repostas a 1 por segundo
+	if result.Allowed || result.Remaining != 0 || result.RetryAfter != 750*time.Millisecond {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRedisStoreErrors(t *testing.T) {
+	policy := Policy{Name: "api", Limit: 5, Period: time.Minute}
+
+	failing := newFakeRedis(nil)
+	failing.err = errors.New("connection refused")
+
+	for name, client := range map[string]*fakeRedis{
+		"redis error":     failing,
+		"malformed reply": newFakeRedis("OK"),
+		"invalid tokens":  newFakeRedis([]interface{}{int64(1), "many"}),
+	} {
+		if _, err := NewRedisStore(client).Take(t.Context(), "client", policy, time.Now()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/user"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Implementações em memória usadas nos testes. Reproduzem as regras que o
+// banco garante (slug único, idempotência por usuário) para que os serviços
+// se comportem como em produção.
+
+type InMemoryQRCodeRepository struct {
+	mu      sync.RWMutex
+	qrCodes []models.QRCode
+}
+
+func NewInMemoryQRCodeRepository(qrCodes ...models.QRCode) *InMemoryQRCodeRepository {
+	return &InMemoryQRCodeRepository{qrCodes: qrCodes}
+}
+
+func (r *InMemoryQRCodeRepository) Insert(ctx context.Context, qrCode models.QRCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.qrCodes {
+		if existing.ID == qrCode.ID || existing.Slug == qrCode.Slug {
+			return ErrDuplicate
+		}
+	}
+
+	r.qrCodes = append(r.qrCodes, qrCode)
+	return nil
+}
+
+func (r *InMemoryQRCodeRepository) FindById(ctx context.Context, id primitive.ObjectID) (models.QRCode, error) {
+	return r.findOne(func(qrCode models.QRCode) bool { return qrCode.ID == id })
+}
+
+func (r *InMemoryQRCodeRepository) FindBySlug(ctx context.Context, slug string) (models.QRCode, error) {
+	return r.findOne(func(qrCode models.QRCode) bool { return qrCode.Slug == slug })
+}
+
+func (r *InMemoryQRCodeRepository) FindAll(ctx context.Context, filter QRCodeFilter) ([]models.QRCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []models.QRCode{}
+
+	for _, qrCode := range r.qrCodes {
+		if filter.UserId != "" && qrCode.UserId != filter.UserId {
+			continue
+		}
+		if filter.WorkspaceId != "" && qrCode.WorkspaceId != filter.WorkspaceId {
+			continue
+		}
+		result = append(result, qrCode)
+	}
+
+	return result, nil
+}
+
+func (r *InMemoryQRCodeRepository) SetImageKey(ctx context.Context, id primitive.ObjectID, imageKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.qrCodes {
+		if r.qrCodes[i].ID == id {
+			r.qrCodes[i].ImageKey = imageKey
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *InMemoryQRCodeRepository) findOne(match func(models.QRCode) bool) (models.QRCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, qrCode := range r.qrCodes {
+		if match(qrCode) {
+			return qrCode, nil
+		}
+	}
+
+	return models.QRCode{}, ErrNotFound
+}
+
+type InMemoryScanRepository struct {
+	mu    sync.RWMutex
+	scans []models.Scan
+}
+
+func NewInMemoryScanRepository(scans ...models.Scan) *InMemoryScanRepository {
+	return &InMemoryScanRepository{scans: scans}
+}
+
+func (r *InMemoryScanRepository) Insert(ctx context.Context, scan models.Scan) (models.Scan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if scan.ID.IsZero() {
+		scan.ID = primitive.NewObjectID()
+	}
+
+	r.scans = append(r.scans, scan)
+	return scan, nil
+}
+
+func (r *InMemoryScanRepository) InsertMany(ctx context.Context, scans []models.Scan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := map[primitive.ObjectID]bool{}
+	for _, scan := range r.scans {
+		existing[scan.ID] = true
+	}
+
+	for _, scan := range scans {
+		if scan.ID.IsZero() {
+			scan.ID = primitive.NewObjectID()
+		}
+		if !existing[scan.ID] {
+			r.scans = append(r.scans, scan)
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryScanRepository) FindNear(ctx context.Context, qrCode models.QRCode, maxDistance int64) ([]models.Scan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type scanWithDistance struct {
+		scan     models.Scan
+		distance float64
+	}
+
+	var matches []scanWithDistance
+
+	for _, scan := range r.scans {
+		if scan.QRCodeId != qrCode.ID || scan.IsBot {
+			continue
+		}
+
+		distance := distanceInMeters(qrCode.Location, scan.Location)
+
+		if distance <= float64(maxDistance) {
+			matches = append(matches, scanWithDistance{scan, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	result := make([]models.Scan, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match.scan)
+	}
+
+	return result, nil
+}
+
+func (r *InMemoryScanRepository) CountByDay(ctx context.Context, qrCodeId primitive.ObjectID, filter ScanStatsFilter) ([]DailyScanCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[string]*DailyScanCount{}
+	visitors := map[string]map[string]bool{}
+
+	for _, scan := range r.scans {
+		if scan.QRCodeId != qrCodeId || (scan.IsBot && !filter.IncludeBots) {
+			continue
+		}
+		if scan.ScanedAt.Before(filter.From) || !scan.ScanedAt.Before(filter.To) {
+			continue
+		}
+
+		day := scan.ScanedAt.UTC().Format(time.DateOnly)
+
+		if counts[day] == nil {
+			counts[day] = &DailyScanCount{Date: day}
+			visitors[day] = map[string]bool{}
+		}
+
+		counts[day].Total++
+
+		if scan.VisitorId != "" && !visitors[day][scan.VisitorId] {
+			visitors[day][scan.VisitorId] = true
+			counts[day].Unique++
+		}
+	}
+
+	result := []DailyScanCount{}
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+
+	return result, nil
+}
+
+// All devolve uma cópia dos scans gravados, para asserções nos testes.
+func (r *InMemoryScanRepository) All() []models.Scan {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Scan{}, r.scans...)
+}
+
+type InMemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]user.User
+}
+
+func NewInMemoryUserRepository(users ...user.User) *InMemoryUserRepository {
+	repository := &InMemoryUserRepository{users: map[string]user.User{}}
+
+	for _, u := range users {
+		repository.users[u.Id] = u
+	}
+
+	return repository
+}
+
+func (r *InMemoryUserRepository) FindById(ctx context.Context, id string) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &found, nil
+}
+
+// distanceInMeters usa a fórmula de haversine com o mesmo raio terrestre do
+// $nearSphere do MongoDB.
+func distanceInMeters(a models.Location, b models.Location) float64 {
+	const earthRadius = 6378100.0
+
+	if len(a.Coordinates) != 2 || len(b.Coordinates) != 2 {
+		return math.Inf(1)
+	}
+
+	lat1 := a.Coordinates[1] * math.Pi / 180
+	lat2 := b.Coordinates[1] * math.Pi / 180
+	deltaLat := lat2 - lat1
+	deltaLong := (b.Coordinates[0] - a.Coordinates[0]) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLong/2)*math.Sin(deltaLong/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"qr-code-boost/src/mongo/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("duplicate")
+)
+
+// QRCodeFilter com todos os campos vazios retorna todos os QR Codes.
+type QRCodeFilter struct {
+	UserId      string
+	WorkspaceId string
+}
+
+type QRCodeRepository interface {
+	Insert(ctx context.Context, qrCode models.QRCode) error
+	FindById(ctx context.Context, id primitive.ObjectID) (models.QRCode, error)
+	FindBySlug(ctx context.Context, slug string) (models.QRCode, error)
+	FindAll(ctx context.Context, filter QRCodeFilter) ([]models.QRCode, error)
+	SetImageKey(ctx context.Context, id primitive.ObjectID, imageKey string) error
+}
+
+// QRCodeChange descreve uma alteração na collection recebida pelo change
+// stream. Em remoções apenas o ID é conhecido.
+type QRCodeChange struct {
+	Operation string
+	Id        primitive.ObjectID
+	Slug      string
+}
+
+// Change streams só existem em replica sets e clusters shardados
+var ErrChangeStreamsUnsupported = errors.New("change streams unsupported")
+
+// Código do MongoDB para "$changeStream is only supported on replica sets"
+const changeStreamUnsupportedCode = 40573
+
+type MongoQRCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoQRCodeRepository(db *mongo.Database) *MongoQRCodeRepository {
+	return &MongoQRCodeRepository{collection: db.Collection("qrcodes")}
+}
+
+func (r *MongoQRCodeRepository) Insert(ctx context.Context, qrCode models.QRCode) error {
+	_, err := r.collection.InsertOne(ctx, qrCode)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+func (r *MongoQRCodeRepository) FindById(ctx context.Context, id primitive.ObjectID) (models.QRCode, error) {
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (r *MongoQRCodeRepository) FindBySlug(ctx context.Context, slug string) (models.QRCode, error) {
+	return r.findOne(ctx, bson.D{{Key: "slug", Value: slug}})
+}
+
+func (r *MongoQRCodeRepository) FindAll(ctx context.Context, filter QRCodeFilter) ([]models.QRCode, error) {
+	query := bson.D{}
+
+	if filter.UserId != "" {
+		query = append(query, bson.E{Key: "userId", Value: filter.UserId})
+	}
+	if filter.WorkspaceId != "" {
+		query = append(query, bson.E{Key: "workspaceId", Value: filter.WorkspaceId})
+	}
+
+	cursor, err := r.collection.Find(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qrCodes := []models.QRCode{}
+
+	if err := cursor.All(ctx, &qrCodes); err != nil {
+		return nil, err
+	}
+
+	return qrCodes, nil
+}
+
+func (r *MongoQRCodeRepository) SetImageKey(ctx context.Context, id primitive.ObjectID, imageKey string) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "imageKey", Value: imageKey}}}}
+
+	_, err := r.collection.UpdateByID(ctx, id, update)
+	return err
+}
+
+// Watch chama onChange para cada inserção, alteração ou remoção de QR Code
+// até ctx ser cancelado ou o stream falhar.
+func (r *MongoQRCodeRepository) Watch(ctx context.Context, onChange func(QRCodeChange)) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{
+			{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}},
+		}}}}},
+	}
+
+	stream, err := r.collection.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+
+	if err != nil {
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && commandErr.Code == changeStreamUnsupportedCode {
+			return ErrChangeStreamsUnsupported
+		}
+		return err
+	}
+
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string `bson:"operationType"`
+			DocumentKey   struct {
+				ID primitive.ObjectID `bson:"_id"`
+			} `bson:"documentKey"`
+			FullDocument *models.QRCode `bson:"fullDocument"`
+		}
+
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		change := QRCodeChange{Operation: event.OperationType, Id: event.DocumentKey.ID}
+
+		if event.FullDocument != nil {
+			change.Slug = event.FullDocument.Slug
+		}
+
+		onChange(change)
+	}
+
+	return stream.Err()
+}
+
+func (r *MongoQRCodeRepository) findOne(ctx context.Context, filter bson.D) (models.QRCode, error) {
+	var result models.QRCode
+
+	err := r.collection.FindOne(ctx, filter).Decode(&result)
+
+	if err == mongo.ErrNoDocuments {
+		return models.QRCode{}, ErrNotFound
+	}
+
+	return result, err
+}
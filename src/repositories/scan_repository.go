@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"qr-code-boost/src/mongo/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScanStatsFilter struct {
+	From        time.Time
+	To          time.Time
+	IncludeBots bool
+}
+
+type DailyScanCount struct {
+	Date  string `json:"date" bson:"_id"` // AAAA-MM-DD em UTC
+	Total int64  `json:"total" bson:"total"`
+	// Scans anteriores ao ID de visitante não entram na contagem de únicos
+	Unique int64 `json:"unique" bson:"unique"`
+}
+
+type ScanRepository interface {
+	Insert(ctx context.Context, scan models.Scan) (models.Scan, error)
+	// InsertMany recebe scans com ID já definido, então repetir um lote que
+	// falhou no meio não duplica os scans já gravados.
+	InsertMany(ctx context.Context, scans []models.Scan) error
+	// FindNear retorna os scans do QR Code a até maxDistance metros dele,
+	// do mais próximo para o mais distante. Scans de bots ficam de fora.
+	FindNear(ctx context.Context, qrCode models.QRCode, maxDistance int64) ([]models.Scan, error)
+	// CountByDay agrupa os scans do período por dia, em ordem cronológica.
+	// Os únicos são sempre exatos: como o ID de visitante muda todo dia, um
+	// sketch aproximado por dia não economizaria nada em relação ao $group
+	// feito dentro do banco.
+	CountByDay(ctx context.Context, qrCodeId primitive.ObjectID, filter ScanStatsFilter) ([]DailyScanCount, error)
+}
+
+// Scans anteriores à detecção de bots não têm o campo e contam como pessoas.
+var notBot = bson.E{Key: "isBot", Value: bson.D{{Key: "$ne", Value: true}}}
+
+type MongoScanRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoScanRepository(db *mongo.Database) *MongoScanRepository {
+	return &MongoScanRepository{collection: db.Collection("scans")}
+}
+
+func (r *MongoScanRepository) Insert(ctx context.Context, scan models.Scan) (models.Scan, error) {
+	result, err := r.collection.InsertOne(ctx, scan)
+
+	if err != nil {
+		return models.Scan{}, err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		scan.ID = oid
+	}
+
+	return scan, nil
+}
+
+func (r *MongoScanRepository) InsertMany(ctx context.Context, scans []models.Scan) error {
+	documents := make([]interface{}, len(scans))
+	for i, scan := range scans {
+		documents[i] = scan
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && onlyDuplicateKeys(bulkErr.WriteErrors) {
+		return nil
+	}
+
+	return err
+}
+
+func onlyDuplicateKeys(writeErrors []mongo.BulkWriteError) bool {
+	for _, writeErr := range writeErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+
+	return len(writeErrors) > 0
+}
+
+func (r *MongoScanRepository) FindNear(ctx context.Context, qrCode models.QRCode, maxDistance int64) ([]models.Scan, error) {
+	filter := bson.D{
+		{
+			Key: "location",
+			Value: bson.D{
+				{
+					// Usando o operador "$nearSphere" para GeoJSON
+					Key: "$nearSphere",
+					Value: bson.D{
+						{Key: "$geometry", Value: qrCode.Location},
+						{Key: "$maxDistance", Value: maxDistance},
+					},
+				},
+			},
+		},
+		{Key: "qrCodeId", Value: qrCode.ID},
+		notBot,
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nearbyScans := []models.Scan{}
+
+	if err := cursor.All(ctx, &nearbyScans); err != nil {
+		return nil, err
+	}
+
+	return nearbyScans, nil
+}
+
+func (r *MongoScanRepository) CountByDay(ctx context.Context, qrCodeId primitive.ObjectID, filter ScanStatsFilter) ([]DailyScanCount, error) {
+	match := bson.D{
+		{Key: "qrCodeId", Value: qrCodeId},
+		{Key: "scanedAt", Value: bson.D{{Key: "$gte", Value: filter.From}, {Key: "$lt", Value: filter.To}}},
+	}
+
+	if !filter.IncludeBots {
+		match = append(match, notBot)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// Primeiro um grupo por dia e visitante, depois só por dia
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "day", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+					{Key: "format", Value: "%Y-%m-%d"},
+					{Key: "date", Value: "$scanedAt"},
+				}}}},
+				{Key: "visitorId", Value: "$visitorId"},
+			}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id.day"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$total"}}},
+			{Key: "unique", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$_id.visitorId", false}}}, 1, 0,
+			}}}}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []DailyScanCount{}
+
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoScanRepositoryCountByDay(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	qrCodeId := primitive.NewObjectID()
+	filter := ScanStatsFilter{
+		From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	mt.Run("decodes the daily counts", func(mt *mtest.T) {
+		repository := NewMongoScanRepository(mt.DB)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".scans", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "2026-03-01"}, {Key: "total", Value: int64(5)}, {Key: "unique", Value: int64(3)}},
+			bson.D{{Key: "_id", Value: "2026-03-02"}, {Key: "total", Value: int64(1)}, {Key: "unique", Value: int64(0)}},
+		))
+
+		counts, err := repository.CountByDay(t.Context(), qrCodeId, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []DailyScanCount{{Date: "2026-03-01", Total: 5, Unique: 3}, {Date: "2026-03-02", Total: 1, Unique: 0}}
+		if len(counts) != len(want) || counts[0] != want[0] || counts[1] != want[1] {
+			t.Errorf("counts = %+v, want %+v", counts, want)
+		}
+	})
+
+	cases := []struct {
+		name        string
+		includeBots bool
+	}{
+		{"bots excluded", false},
+		{"bots included", true},
+	}
+
+	for _, tc := range cases {
+		includeBots := tc.includeBots
+
+		mt.Run(tc.name, func(mt *mtest.T) {
+			repository := NewMongoScanRepository(mt.DB)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, mt.DB.Name()+".scans", mtest.FirstBatch))
+
+			filter := filter
+			filter.IncludeBots = includeBots
+
+			if _, err := repository.CountByDay(t.Context(), qrCodeId, filter); err != nil {
+				t.Fatal(err)
+			}
+
+			started := mt.GetStartedEvent()
+			if started == nil || started.CommandName != "aggregate" {
+				t.Fatalf("command = %v, want aggregate", started)
+			}
+
+			match := started.Command.Lookup("pipeline", "0", "$match").Document()
+
+			if got, ok := match.Lookup("qrCodeId").ObjectIDOK(); !ok || got != qrCodeId {
+				t.Errorf("$match qrCodeId = %v, want %v", match.Lookup("qrCodeId"), qrCodeId)
+			}
+
+			if _, err := match.LookupErr("scanedAt", "$gte"); err != nil {
+				t.Errorf("$match has no scanedAt range: %v", match)
+			}
+
+			_, err := match.LookupErr("isBot")
+			if excludesBots := err == nil; excludesBots == includeBots {
+				t.Errorf("$match = %v, bots excluded = %v with includeBots = %v", match, excludesBots, includeBots)
+			}
+		})
+	}
+}
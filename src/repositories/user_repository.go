@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"qr-code-boost/src/user"
+)
+
+type UserRepository interface {
+	// FindById retorna nil quando o usuário não existe.
+	FindById(ctx context.Context, id string) (*user.User, error)
+}
+
+// PostgresUserRepository delega para as queries do pacote user.
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func (r *PostgresUserRepository) FindById(ctx context.Context, id string) (*user.User, error) {
+	return user.FindById(ctx, id, r.db)
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VisitorSaltRepository guarda o sal aleatório de cada dia. Os documentos
+// expiram pelo índice TTL, e sem o sal não é possível refazer o hash de um IP.
+type VisitorSaltRepository interface {
+	// FindOrCreate devolve o sal já gravado para o dia ou grava candidate.
+	// Réplicas concorrentes sempre recebem o mesmo valor.
+	FindOrCreate(ctx context.Context, day string, candidate []byte) ([]byte, error)
+}
+
+type visitorSalt struct {
+	Day       string    `bson:"_id"`
+	Salt      []byte    `bson:"salt"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type MongoVisitorSaltRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoVisitorSaltRepository(db *mongo.Database) *MongoVisitorSaltRepository {
+	return &MongoVisitorSaltRepository{collection: db.Collection("visitor_salts")}
+}
+
+func (r *MongoVisitorSaltRepository) FindOrCreate(ctx context.Context, day string, candidate []byte) ([]byte, error) {
+	update := bson.D{{Key: "$setOnInsert", Value: bson.D{
+		{Key: "salt", Value: candidate},
+		{Key: "createdAt", Value: time.Now()},
+	}}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result visitorSalt
+	err := r.collection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: day}}, update, opts).Decode(&result)
+
+	// Duas réplicas inserindo o mesmo dia: quem perdeu lê o sal do vencedor
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: day}}).Decode(&result)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Salt, nil
+}
+
+type InMemoryVisitorSaltRepository struct {
+	mu    sync.Mutex
+	salts map[string][]byte
+}
+
+func NewInMemoryVisitorSaltRepository() *InMemoryVisitorSaltRepository {
+	return &InMemoryVisitorSaltRepository{salts: map[string][]byte{}}
+}
+
+func (r *InMemoryVisitorSaltRepository) FindOrCreate(ctx context.Context, day string, candidate []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if salt, ok := r.salts[day]; ok {
+		return salt, nil
+	}
+
+	r.salts[day] = candidate
+	return candidate, nil
+}
@@ -0,0 +1,46 @@
+package scan
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Trechos de User-Agent de bots conhecidos, em minúsculas. Pré-visualizações
+// de links (Slack, WhatsApp, Facebook...) são a maior fonte de scans falsos.
+var botUserAgents = []string{
+	"bot", "crawler", "spider", "slurp", "crawl",
+	"facebookexternalhit", "facebookcatalog", "meta-externalagent",
+	"whatsapp", "slack", "telegram", "discord", "skypeuripreview",
+	"embedly", "pinterest", "vkshare", "quora link preview", "iframely",
+	"headlesschrome", "phantomjs", "lighthouse", "pingdom", "uptimerobot",
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp",
+	"go-http-client", "okhttp", "java/", "apache-httpclient", "axios/", "node-fetch",
+	"libwww-perl", "httpie", "postmanruntime",
+}
+
+// IsBotRequest classifica a requisição pelo User-Agent e por sinais que
+// navegadores de pessoas não enviam.
+func IsBotRequest(r *http.Request) bool {
+	userAgent := strings.ToLower(strings.TrimSpace(r.UserAgent()))
+
+	// Navegadores sempre se identificam
+	if userAgent == "" {
+		return true
+	}
+
+	for _, token := range botUserAgents {
+		if strings.Contains(userAgent, token) {
+			return true
+		}
+	}
+
+	// Prefetch do navegador ou de apps de mensagem, sem ninguém abrir o link
+	for _, header := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		value := strings.ToLower(r.Header.Get(header))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,192 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"qr-code-boost/src/logging"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrQueueFull      = errors.New("scan queue is full")
+	ErrIngesterClosed = errors.New("scan ingester is closed")
+)
+
+type IngesterOptions struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	// Com a fila cheia, Enqueue espera até este tempo antes de descartar o scan.
+	// Segura um pouco o redirecionamento em vez de perder o evento de imediato.
+	EnqueueTimeout time.Duration
+}
+
+type IngesterStats struct {
+	QueueLength   int    `json:"queueLength"`
+	QueueCapacity int    `json:"queueCapacity"`
+	Enqueued      uint64 `json:"enqueued"`
+	Inserted      uint64 `json:"inserted"`
+	Blocked       uint64 `json:"blocked"` // Enqueue precisou esperar por espaço na fila
+	Dropped       uint64 `json:"dropped"` // Descartados com a fila cheia ou após o fechamento
+	Failed        uint64 `json:"failed"`  // Perdidos após esgotar as tentativas de inserção
+	Batches       uint64 `json:"batches"`
+}
+
+// Ingester grava os scans em lotes fora do caminho do redirecionamento. Uma
+// única goroutine consome a fila e chama InsertMany quando o lote enche ou a
+// cada FlushInterval.
+type Ingester struct {
+	scans repositories.ScanRepository
+	opts  IngesterOptions
+	queue chan models.Scan
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued atomic.Uint64
+	inserted atomic.Uint64
+	blocked  atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// Tentativas de InsertMany antes de um lote ser considerado perdido
+const insertAttempts = 3
+
+func NewIngester(scans repositories.ScanRepository, opts IngesterOptions) *Ingester {
+	ingester := &Ingester{
+		scans: scans,
+		opts:  opts,
+		queue: make(chan models.Scan, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+
+	go ingester.run()
+
+	return ingester
+}
+
+func (i *Ingester) Enqueue(scan models.Scan) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.closed {
+		i.dropped.Add(1)
+		return ErrIngesterClosed
+	}
+
+	select {
+	case i.queue <- scan:
+		i.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	i.blocked.Add(1)
+
+	timer := time.NewTimer(i.opts.EnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case i.queue <- scan:
+		i.enqueued.Add(1)
+		return nil
+	case <-timer.C:
+		i.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Close para de aceitar scans e espera a fila ser gravada. Se ctx expirar
+// antes, retorna o erro do contexto com a fila ainda sendo esvaziada.
+func (i *Ingester) Close(ctx context.Context) error {
+	i.mu.Lock()
+	if !i.closed {
+		i.closed = true
+		close(i.queue)
+	}
+	i.mu.Unlock()
+
+	select {
+	case <-i.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (i *Ingester) Stats() IngesterStats {
+	return IngesterStats{
+		QueueLength:   len(i.queue),
+		QueueCapacity: cap(i.queue),
+		Enqueued:      i.enqueued.Load(),
+		Inserted:      i.inserted.Load(),
+		Blocked:       i.blocked.Load(),
+		Dropped:       i.dropped.Load(),
+		Failed:        i.failed.Load(),
+		Batches:       i.batches.Load(),
+	}
+}
+
+func (i *Ingester) run() {
+	defer close(i.done)
+
+	ticker := time.NewTicker(i.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Scan, 0, i.opts.BatchSize)
+
+	for {
+		select {
+		case scan, ok := <-i.queue:
+			if !ok {
+				i.flush(batch)
+				return
+			}
+
+			batch = append(batch, scan)
+
+			if len(batch) >= i.opts.BatchSize {
+				i.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				i.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+func (i *Ingester) flush(batch []models.Scan) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= insertAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = i.scans.InsertMany(ctx, batch)
+		cancel()
+
+		if err == nil {
+			i.inserted.Add(uint64(len(batch)))
+			i.batches.Add(1)
+			return
+		}
+
+		slog.Error("Erro ao gravar lote de scans", "size", len(batch), "attempt", attempt, "maxAttempts", insertAttempts, logging.Err(err))
+		time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+	}
+
+	i.failed.Add(uint64(len(batch)))
+}
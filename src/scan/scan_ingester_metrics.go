@@ -0,0 +1,48 @@
+package scan
+
+import (
+	"qr-code-boost/src/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func ingesterDesc(name string, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "scan_ingester", name), help, nil, nil)
+}
+
+var (
+	ingesterQueueLength   = ingesterDesc("queue_length", "Scans aguardando gravação na fila.")
+	ingesterQueueCapacity = ingesterDesc("queue_capacity", "Capacidade da fila de scans.")
+	ingesterEnqueued      = ingesterDesc("enqueued_total", "Scans aceitos na fila.")
+	ingesterInserted      = ingesterDesc("inserted_total", "Scans gravados no MongoDB.")
+	ingesterBlocked       = ingesterDesc("blocked_total", "Scans que esperaram espaço na fila.")
+	ingesterDropped       = ingesterDesc("dropped_total", "Scans descartados com a fila cheia ou fechada.")
+	ingesterFailed        = ingesterDesc("failed_total", "Scans perdidos após esgotar as tentativas de inserção.")
+	ingesterBatches       = ingesterDesc("batches_total", "Lotes gravados no MongoDB.")
+)
+
+// Describe e Collect fazem do Ingester um prometheus.Collector, lido a partir
+// de Stats a cada coleta.
+func (i *Ingester) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ingesterQueueLength
+	ch <- ingesterQueueCapacity
+	ch <- ingesterEnqueued
+	ch <- ingesterInserted
+	ch <- ingesterBlocked
+	ch <- ingesterDropped
+	ch <- ingesterFailed
+	ch <- ingesterBatches
+}
+
+func (i *Ingester) Collect(ch chan<- prometheus.Metric) {
+	stats := i.Stats()
+
+	ch <- prometheus.MustNewConstMetric(ingesterQueueLength, prometheus.GaugeValue, float64(stats.QueueLength))
+	ch <- prometheus.MustNewConstMetric(ingesterQueueCapacity, prometheus.GaugeValue, float64(stats.QueueCapacity))
+	ch <- prometheus.MustNewConstMetric(ingesterEnqueued, prometheus.CounterValue, float64(stats.Enqueued))
+	ch <- prometheus.MustNewConstMetric(ingesterInserted, prometheus.CounterValue, float64(stats.Inserted))
+	ch <- prometheus.MustNewConstMetric(ingesterBlocked, prometheus.CounterValue, float64(stats.Blocked))
+	ch <- prometheus.MustNewConstMetric(ingesterDropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(ingesterFailed, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(ingesterBatches, prometheus.CounterValue, float64(stats.Batches))
+}
@@ -0,0 +1,117 @@
+package scan
+
+import (
+	"context"
+	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// blockingScanRepository segura o InsertMany até release ser fechado, para
+// encher a fila do ingester.
+type blockingScanRepository struct {
+	*repositories.InMemoryScanRepository
+	release chan struct{}
+}
+
+func (r *blockingScanRepository) InsertMany(ctx context.Context, scans []models.Scan) error {
+	<-r.release
+	return r.InMemoryScanRepository.InsertMany(ctx, scans)
+}
+
+func newScan() models.Scan {
+	return models.Scan{ID: primitive.NewObjectID(), QRCodeId: primitive.NewObjectID(), ScanedAt: time.Now()}
+}
+
+func TestIngesterDrainsQueueOnClose(t *testing.T) {
+	repository := repositories.NewInMemoryScanRepository()
+	ingester := NewIngester(repository, IngesterOptions{
+		QueueSize:     100,
+		BatchSize:     10,
+		FlushInterval: time.Hour, // Só lotes cheios e o Close gravam
+	})
+
+	for range 25 {
+		if err := ingester.Enqueue(newScan()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ingester.Close(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(repository.All()); got != 25 {
+		t.Errorf("inserted %d scans, want 25", got)
+	}
+
+	stats := ingester.Stats()
+	if stats.Inserted != 25 || stats.Batches != 3 {
+		t.Errorf("stats = %+v, want 25 inserted in 3 batches", stats)
+	}
+
+	if err := ingester.Enqueue(newScan()); err != ErrIngesterClosed {
+		t.Errorf("enqueue after close = %v, want ErrIngesterClosed", err)
+	}
+}
+
+func TestIngesterFlushesOnInterval(t *testing.T) {
+	repository := repositories.NewInMemoryScanRepository()
+	ingester := NewIngester(repository, IngesterOptions{
+		QueueSize:     100,
+		BatchSize:     100,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	defer ingester.Close(context.Background())
+
+	ingester.Enqueue(newScan())
+
+	deadline := time.Now().Add(time.Second)
+	for len(repository.All()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scan was not flushed by the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIngesterDropsWhenQueueIsFull(t *testing.T) {
+	repository := &blockingScanRepository{
+		InMemoryScanRepository: repositories.NewInMemoryScanRepository(),
+		release:                make(chan struct{}),
+	}
+
+	ingester := NewIngester(repository, IngesterOptions{
+		QueueSize:      2,
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		EnqueueTimeout: time.Millisecond,
+	})
+
+	// Um scan fica preso no InsertMany e dois ocupam a fila
+	var dropped int
+	for range 5 {
+		if err := ingester.Enqueue(newScan()); err == ErrQueueFull {
+			dropped++
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if dropped != 2 {
+		t.Errorf("dropped %d scans, want 2", dropped)
+	}
+
+	close(repository.release)
+
+	if err := ingester.Close(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := ingester.Stats()
+	if stats.Inserted != 3 || stats.Dropped != 2 || stats.Blocked < 2 {
+		t.Errorf("stats = %+v, want 3 inserted and 2 dropped", stats)
+	}
+}
@@ -2,122 +2,153 @@ package scan
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
+	"net/netip"
+	"qr-code-boost/src/logging"
 	"qr-code-boost/src/mongo/models"
+	"qr-code-boost/src/repositories"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateScanDto struct {
 	QRCodeId primitive.ObjectID `bson:"qrCodeId"`
 	Lat      *float64           `bson:"lat"`
 	Long     *float64           `bson:"long"`
+	IsBot    bool               `bson:"isBot"`
+
+	// Usados apenas para gerar o ID de visitante; não são gravados
+	ClientIP  netip.Addr `bson:"-"`
+	UserAgent string     `bson:"-"`
 }
 
 type FindNearScansFilterDto struct {
 	MaxDistance *int64
 }
 
-func Create(dto CreateScanDto, client *mongo.Client) (models.Scan, error) {
-	fmt.Printf("Criando scan para QR Code ID: %s\n", dto.QRCodeId.Hex())
+type ScanStats struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	IncludeBots bool      `json:"includeBots"`
+	Total       int64     `json:"total"`
+	// Soma dos únicos de cada dia. O ID de visitante muda diariamente, então
+	// quem volta em outro dia é contado de novo.
+	Unique int64                         `json:"unique"`
+	Days   []repositories.DailyScanCount `json:"days"`
+}
 
-	_, err := FindQRCodeById(dto.QRCodeId, client)
+// Período usado quando a consulta de estatísticas não informa o início
+const defaultStatsPeriod = 30 * 24 * time.Hour
 
-	if err != nil {
-		fmt.Printf("Erro ao encontrar QR Code: %v\n", err)
-		return models.Scan{}, err
-	}
+type ScanService struct {
+	Scans repositories.ScanRepository
+	// Com Ingester os scans são gravados em lotes, em segundo plano; sem ele,
+	// um a um na própria requisição.
+	Ingester           *Ingester
+	Visitors           *VisitorHasher
+	DefaultMaxDistance int64
+}
 
-	coll := client.Database("qr-code-boost").Collection("scans")
+func NewScanService(scans repositories.ScanRepository, ingester *Ingester, visitors *VisitorHasher, defaultMaxDistance int64) *ScanService {
+	return &ScanService{
+		Scans:              scans,
+		Ingester:           ingester,
+		Visitors:           visitors,
+		DefaultMaxDistance: defaultMaxDistance,
+	}
+}
 
+// Create recebe o ID de um QR Code já encontrado por quem chama, sem buscá-lo
+// de novo.
+func (s *ScanService) Create(ctx context.Context, dto CreateScanDto) (models.Scan, error) {
 	newScan := models.Scan{
+		ID:       primitive.NewObjectID(),
 		QRCodeId: dto.QRCodeId,
 		Location: models.Location{
 			Type:        "Point",
 			Coordinates: []float64{*dto.Long, *dto.Lat},
 		},
 		ScanedAt: time.Now(),
+		IsBot:    dto.IsBot,
 	}
 
-	result, err := coll.InsertOne(context.TODO(), newScan)
+	if s.Visitors != nil && dto.ClientIP.IsValid() {
+		visitorId, err := s.Visitors.VisitorId(ctx, dto.ClientIP, dto.UserAgent, newScan.ScanedAt)
 
-	if err != nil {
-		fmt.Printf("Erro ao inserir scan: %v\n", err)
-		panic(err)
+		// Sem o ID o scan ainda vale para o total, então não é descartado
+		if err != nil {
+			slog.ErrorContext(ctx, "Erro ao gerar ID de visitante", logging.QRCodeId(dto.QRCodeId), logging.Err(err))
+		}
+
+		newScan.VisitorId = visitorId
 	}
 
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		newScan.ID = oid
+	if s.Ingester != nil {
+		if err := s.Ingester.Enqueue(newScan); err != nil {
+			slog.WarnContext(ctx, "Scan descartado", logging.QRCodeId(dto.QRCodeId), logging.Err(err))
+			return models.Scan{}, err
+		}
+
+		return newScan, nil
 	}
 
-	fmt.Printf("Scan criado com sucesso: %s\n", newScan.ID.Hex())
+	newScan, err := s.Scans.Insert(ctx, newScan)
 
-	return newScan, nil
-}
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao inserir scan", logging.QRCodeId(dto.QRCodeId), logging.Err(err))
+		return models.Scan{}, err
+	}
 
-func FindQRCodeById(qrCodeId primitive.ObjectID, client *mongo.Client) (models.QRCode, error) {
-	coll := client.Database("qr-code-boost").Collection("qrcodes")
+	slog.DebugContext(ctx, "Scan criado", "scanId", newScan.ID.Hex(), logging.QRCodeId(dto.QRCodeId))
 
-	fmt.Println("Buscando QR Code por ID:", qrCodeId)
+	return newScan, nil
+}
 
-	filter := bson.D{{Key: "_id", Value: qrCodeId}}
+func (s *ScanService) FindNearScans(ctx context.Context, filterDto FindNearScansFilterDto, qrCode models.QRCode) ([]models.Scan, error) {
+	maxDistance := s.DefaultMaxDistance
+	if filterDto.MaxDistance != nil {
+		maxDistance = *filterDto.MaxDistance
+	}
 
-	var result models.QRCode
-	err := coll.FindOne(context.TODO(), filter).Decode(&result)
+	nearbyScans, err := s.Scans.FindNear(ctx, qrCode, maxDistance)
 
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			fmt.Printf("QR Code não encontrado: %s\n", qrCodeId)
-			return models.QRCode{}, err
-		}
-		panic(err)
+		slog.ErrorContext(ctx, "Erro ao buscar scans próximos", logging.QRCodeId(qrCode.ID), logging.Err(err))
+		return nil, err
 	}
 
-	return result, nil
+	return nearbyScans, nil
 }
 
-func FindNearScans(filterDto FindNearScansFilterDto, qrCode models.QRCode, client *mongo.Client) ([]models.Scan, error) {
-	coll := client.Database("qr-code-boost").Collection("scans")
-
-	centerPoint := qrCode.Location
-
-	var defaultDistance int64 = 3000
-	maxDistance := defaultDistance
-	if filterDto.MaxDistance != nil {
-		maxDistance = *filterDto.MaxDistance
+// FindStats conta os scans por dia entre filter.From (inclusivo) e filter.To
+// (exclusivo). Sem datas, considera os últimos 30 dias.
+func (s *ScanService) FindStats(ctx context.Context, qrCode models.QRCode, filter repositories.ScanStatsFilter) (ScanStats, error) {
+	if filter.To.IsZero() {
+		filter.To = time.Now()
 	}
-
-	filter := bson.D{
-		{
-			Key: "location",
-			Value: bson.D{
-				{
-					// 2. Usando o operador "$nearSphere" para GeoJSON
-					Key: "$nearSphere",
-					Value: bson.D{
-						{Key: "$geometry", Value: centerPoint},
-						{Key: "$maxDistance", Value: maxDistance}, // 3km
-					},
-				},
-			},
-		},
-		{Key: "qrCodeId", Value: qrCode.ID},
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-defaultStatsPeriod)
 	}
 
-	var nearbyScans []models.Scan
-	cursor, err := coll.Find(context.TODO(), filter)
+	days, err := s.Scans.CountByDay(ctx, qrCode.ID, filter)
+
 	if err != nil {
-		fmt.Printf("[SCAN SERVICE] Erro ao executar a busca de scans próximos: %v\n", err)
-		return nil, err
+		slog.ErrorContext(ctx, "Erro ao contar scans", logging.QRCodeId(qrCode.ID), logging.Err(err))
+		return ScanStats{}, err
 	}
 
-	if err = cursor.All(context.TODO(), &nearbyScans); err != nil {
-		return nil, err
+	stats := ScanStats{
+		From:        filter.From,
+		To:          filter.To,
+		IncludeBots: filter.IncludeBots,
+		Days:        days,
 	}
 
-	fmt.Printf("Encontrados %d scans próximos.\n", len(nearbyScans))
-	return nearbyScans, nil
+	for _, day := range days {
+		stats.Total += day.Total
+		stats.Unique += day.Unique
+	}
+
+	return stats, nil
 }
@@ -0,0 +1,71 @@
+package scan
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"qr-code-boost/src/repositories"
+	"sync"
+	"time"
+)
+
+// VisitorHasher gera um ID de visitante que identifica a mesma pessoa apenas
+// dentro de um dia (UTC): HMAC-SHA256 de IP + User-Agent com um sal aleatório
+// diário. O IP nunca é gravado e, quando o sal expira, o ID não pode mais ser
+// associado a ele.
+type VisitorHasher struct {
+	Salts repositories.VisitorSaltRepository
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func NewVisitorHasher(salts repositories.VisitorSaltRepository) *VisitorHasher {
+	return &VisitorHasher{Salts: salts}
+}
+
+func (h *VisitorHasher) VisitorId(ctx context.Context, ip netip.Addr, userAgent string, at time.Time) (string, error) {
+	salt, err := h.saltFor(ctx, at.UTC().Format(time.DateOnly))
+
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip.String()))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+
+	// 128 bits bastam para evitar colisões entre visitantes de um mesmo dia
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// saltFor mantém em memória apenas o sal do dia corrente.
+func (h *VisitorHasher) saltFor(ctx context.Context, day string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.day == day {
+		return h.salt, nil
+	}
+
+	candidate := make([]byte, 32)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, err
+	}
+
+	salt, err := h.Salts.FindOrCreate(ctx, day, candidate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.day = day
+	h.salt = salt
+
+	return salt, nil
+}
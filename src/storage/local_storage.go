@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalStorage struct {
+	Dir       string
+	PublicURL string
+}
+
+func NewLocalStorage(dir string, publicURL string) (*LocalStorage, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar diretório de imagens: %v", err)
+	}
+
+	return &LocalStorage{
+		Dir:       dir,
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (s *LocalStorage) Save(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) URL(ctx context.Context, key string) (string, error) {
+	return s.PublicURL + "/" + key, nil
+}
+
+func (s *LocalStorage) List(ctx context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(s.Dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, Object{
+			Key:          entry.Name(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.Dir)
+
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s não é um diretório", s.Dir)
+	}
+
+	return nil
+}
+
+// path impede que uma chave como "../x" escape do diretório configurado.
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || key != filepath.Base(key) {
+		return "", fmt.Errorf("chave de imagem inválida: %q", key)
+	}
+
+	return filepath.Join(s.Dir, key), nil
+}
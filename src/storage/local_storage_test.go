@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageSaveAndDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "images")
+
+	local, err := NewLocalStorage(dir, "/images/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := local.Save(t.Context(), "launch.png", []byte("png"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "launch.png"))
+	if err != nil || string(data) != "png" {
+		t.Fatalf("saved file = %q, %v", data, err)
+	}
+
+	objects, err := local.List(t.Context())
+	if err != nil || len(objects) != 1 || objects[0].Key != "launch.png" {
+		t.Errorf("List = %+v, %v", objects, err)
+	}
+
+	if err := local.Delete(t.Context(), "launch.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "launch.png")); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+
+	// Apagar uma imagem que já não existe não é erro
+	if err := local.Delete(t.Context(), "launch.png"); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	local := &LocalStorage{Dir: t.TempDir(), PublicURL: "https://cdn.example.com/images"}
+
+	url, err := local.URL(t.Context(), "launch.png")
+	if err != nil || url != "https://cdn.example.com/images/launch.png" {
+		t.Errorf("URL = %q, %v", url, err)
+	}
+}
+
+func TestLocalStorageRejectsPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "images")
+
+	local, err := NewLocalStorage(dir, "/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", ".", "..", "../escape.png", "nested/launch.png", "/etc/passwd", "a/../../escape.png"} {
+		if err := local.Save(t.Context(), key, []byte("x"), "image/png"); err == nil {
+			t.Errorf("Save(%q) accepted", key)
+		}
+
+		if err := local.Delete(t.Context(), key); err == nil {
+			t.Errorf("Delete(%q) accepted", key)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escape.png")); !os.IsNotExist(err) {
+		t.Errorf("file written outside the storage dir: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"qr-code-boost/src/config"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage grava as imagens em qualquer serviço compatível com S3
+// (AWS S3, MinIO, Cloudflare R2...).
+type S3Storage struct {
+	Client    *minio.Client
+	Bucket    string
+	PublicURL string
+	SignedTTL time.Duration
+}
+
+func NewS3StorageFromConfig(cfg config.S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("endpoint e bucket são obrigatórios para o storage s3")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyId, cfg.SecretAccessKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar cliente S3: %v", err)
+	}
+
+	return NewS3Storage(client, cfg.Bucket, cfg.PublicURL, cfg.SignedURLTTL)
+}
+
+// NewS3Storage garante que o bucket existe. Quando publicURL é vazio as URLs
+// retornadas são assinadas e expiram após signedTTL.
+func NewS3Storage(client *minio.Client, bucket string, publicURL string, signedTTL time.Duration) (*S3Storage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, bucket)
+
+	if err != nil {
+		return nil, fmt.Errorf("falha ao verificar bucket %s: %v", bucket, err)
+	}
+
+	if !exists {
+		err = client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao criar bucket %s: %v", bucket, err)
+		}
+		slog.Info("Bucket criado", "bucket", bucket)
+	}
+
+	return &S3Storage{
+		Client:    client,
+		Bucket:    bucket,
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+		SignedTTL: signedTTL,
+	}, nil
+}
+
+func (s *S3Storage) Save(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.Client.PutObject(ctx, s.Bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(ctx context.Context, key string) (string, error) {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + key, nil
+	}
+
+	signedURL, err := s.Client.PresignedGetObject(ctx, s.Bucket, key, s.SignedTTL, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return signedURL.String(), nil
+}
+
+// Ping faz um HEAD no bucket.
+func (s *S3Storage) Ping(ctx context.Context) error {
+	exists, err := s.Client.BucketExists(ctx, s.Bucket)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("bucket %s não encontrado", s.Bucket)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+
+	for info := range s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+
+		objects = append(objects, Object{
+			Key:          info.Key,
+			LastModified: info.LastModified,
+		})
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// fakeS3 implementa só o necessário da API S3 (bucket, PUT, DELETE e listagem
+// v2) para exercitar o S3Storage sem um MinIO de verdade.
+type fakeS3 struct {
+	mu           sync.Mutex
+	bucket       string
+	bucketExists bool
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	if bucket != f.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		if !f.bucketExists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodPut:
+		f.bucketExists = true
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w)
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			body = decodeAWSChunked(body)
+		}
+		f.objects[key] = body
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter) {
+	type content struct {
+		Key          string
+		LastModified string
+		Size         int
+	}
+
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: f.bucket}
+
+	for key, body := range f.objects {
+		result.Contents = append(result.Contents, content{Key: key, LastModified: "2026-03-01T12:00:00.000Z", Size: len(body)})
+	}
+
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// decodeAWSChunked remove os cabeçalhos "<tamanho hex>;chunk-signature=..."
+// que o minio-go usa ao assinar o corpo sem TLS.
+func decodeAWSChunked(body []byte) []byte {
+	var data []byte
+
+	for {
+		header, rest, ok := strings.Cut(string(body), "\r\n")
+		if !ok {
+			return data
+		}
+
+		size, err := strconv.ParseInt(strings.Split(header, ";")[0], 16, 64)
+		if err != nil || size == 0 || int(size) > len(rest) {
+			return data
+		}
+
+		data = append(data, rest[:size]...)
+		body = []byte(strings.TrimPrefix(rest[size:], "\r\n"))
+	}
+}
+
+func newFakeS3Storage(t *testing.T, publicURL string) (*S3Storage, *fakeS3) {
+	t.Helper()
+
+	fake := &fakeS3{bucket: "qr-codes", objects: map[string][]byte{}, contentTypes: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	endpoint, _ := url.Parse(server.URL)
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4("access", "secret", ""),
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s3, err := NewS3Storage(client, "qr-codes", publicURL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s3, fake
+}
+
+func TestS3Storage(t *testing.T) {
+	s3, fake := newFakeS3Storage(t, "")
+
+	if !fake.bucketExists {
+		t.Fatal("NewS3Storage did not create the missing bucket")
+	}
+
+	if err := s3.Save(t.Context(), "launch.png", []byte("png"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(fake.objects["launch.png"]) != "png" || fake.contentTypes["launch.png"] != "image/png" {
+		t.Errorf("stored object = %q (%s)", fake.objects["launch.png"], fake.contentTypes["launch.png"])
+	}
+
+	objects, err := s3.List(t.Context())
+	if err != nil || len(objects) != 1 || objects[0].Key != "launch.png" || objects[0].LastModified.IsZero() {
+		t.Errorf("List = %+v, %v", objects, err)
+	}
+
+	signedURL, err := s3.URL(t.Context(), "launch.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _ := url.Parse(signedURL)
+	if parsed.Path != "/qr-codes/launch.png" || parsed.Query().Get("X-Amz-Expires") != "3600" {
+		t.Errorf("signed URL = %s", signedURL)
+	}
+
+	if err := s3.Delete(t.Context(), "launch.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fake.objects["launch.png"]; ok {
+		t.Error("object still stored after Delete")
+	}
+}
+
+func TestS3StoragePublicURL(t *testing.T) {
+	s3, _ := newFakeS3Storage(t, "https://cdn.example.com/qr/")
+
+	publicURL, err := s3.URL(t.Context(), "launch.png")
+	if err != nil || publicURL != "https://cdn.example.com/qr/launch.png" {
+		t.Errorf("URL = %q, %v", publicURL, err)
+	}
+}
+
+func TestS3StoragePing(t *testing.T) {
+	s3, fake := newFakeS3Storage(t, "")
+
+	if err := s3.Ping(t.Context()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	fake.mu.Lock()
+	fake.bucketExists = false
+	fake.mu.Unlock()
+
+	if err := s3.Ping(t.Context()); err == nil {
+		t.Error("Ping passed without the bucket")
+	}
+
+	if len(fake.objects) != 0 {
+		t.Errorf("Ping wrote %v", fake.objects)
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"qr-code-boost/src/config"
+	"time"
+)
+
+// Storage abstrai onde as imagens dos QR Codes são gravadas, permitindo
+// trocar o disco local por um bucket S3 compatível sem alterar os serviços.
+type Storage interface {
+	Save(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(ctx context.Context, key string) (string, error)
+	List(ctx context.Context) ([]Object, error)
+	// Ping verifica se o destino das imagens está acessível sem gravar nada.
+	Ping(ctx context.Context) error
+}
+
+type Object struct {
+	Key          string
+	LastModified time.Time
+}
+
+// New escolhe a implementação a partir do driver configurado ("local" ou "s3").
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return NewS3StorageFromConfig(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage driver desconhecido: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,209 @@
+package user
+
+import (
+	"database/sql"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserController struct {
+	PostgresClient *sql.DB
+}
+
+// @Summary      Create a user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        request body user.CreateUserDto true "User Payload"
+// @Success      201 {object} user.User
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      409 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /users [post]
+func (u *UserController) CreateUser(c *gin.Context) {
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.IsAdmin() {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "user.admin_only_create"))
+		return
+	}
+
+	var createUserDto CreateUserDto
+
+	err := c.ShouldBindJSON(&createUserDto)
+
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	user, err := Create(c.Request.Context(), createUserDto, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(201, user)
+}
+
+// @Summary      List users
+// @Tags         Users
+// @Produce      json
+// @Param        limit query int false "Page size (default: 50, max: 200)"
+// @Param        offset query int false "Items to skip"
+// @Success      200 {array} user.User
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /users [get]
+func (u *UserController) FindAllUsers(c *gin.Context) {
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.IsAdmin() {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "user.admin_only_list"))
+		return
+	}
+
+	limit, errLimit := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, errOffset := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if errLimit != nil || errOffset != nil || limit < 1 || limit > 200 || offset < 0 {
+		apperror.Respond(c, apperror.New(apperror.Invalid, "request.invalid_page"))
+		return
+	}
+
+	users, err := FindAll(c.Request.Context(), limit, offset, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, users)
+}
+
+// @Summary      Find a user
+// @Tags         Users
+// @Produce      json
+// @Param        userId path string true "User ID"
+// @Success      200 {object} user.User
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /users/{userId} [get]
+func (u *UserController) FindUser(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if !u.authorize(c, userId) {
+		return
+	}
+
+	user, err := FindById(c.Request.Context(), userId, u.PostgresClient)
+
+	if err == nil && (user == nil || user.IsDeleted()) {
+		err = ErrUserNotFound
+	}
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, user)
+}
+
+// @Summary      Update a user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        userId path string true "User ID"
+// @Param        request body user.UpdateUserDto true "Fields to update"
+// @Success      200 {object} user.User
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      409 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /users/{userId} [patch]
+func (u *UserController) UpdateUser(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if !u.authorize(c, userId) {
+		return
+	}
+
+	var updateUserDto UpdateUserDto
+
+	err := c.ShouldBindJSON(&updateUserDto)
+
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	user, err := Update(c.Request.Context(), userId, updateUserDto, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, user)
+}
+
+// @Summary      Soft-delete a user
+// @Tags         Users
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /users/{userId} [delete]
+func (u *UserController) DeleteUser(c *gin.Context) {
+	userId := c.Param("userId")
+
+	if !u.authorize(c, userId) {
+		return
+	}
+
+	err := SoftDelete(c.Request.Context(), userId, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.Status(204)
+}
+
+// authorize permite que o próprio usuário ou um admin acesse o cadastro.
+func (u *UserController) authorize(c *gin.Context, userId string) bool {
+	principal, _ := auth.GetPrincipal(c)
+
+	if !principal.CanAccess(userId) {
+		apperror.Respond(c, apperror.New(apperror.Forbidden, "user.forbidden"))
+		return false
+	}
+
+	return true
+}
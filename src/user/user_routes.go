@@ -0,0 +1,19 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      User Routes
+// Os middlewares de proteção vêm de quem registra as rotas, pois o pacote
+// middlewares depende de user para resolver o usuário dos tokens.
+func UsersRouter(r *gin.Engine, userController *UserController, guards ...gin.HandlerFunc) {
+	userRoutes := r.Group("/users", guards...)
+	{
+		userRoutes.POST("/", userController.CreateUser)
+		userRoutes.GET("/", userController.FindAllUsers)
+		userRoutes.GET("/:userId", userController.FindUser)
+		userRoutes.PATCH("/:userId", userController.UpdateUser)
+		userRoutes.DELETE("/:userId", userController.DeleteUser)
+	}
+}
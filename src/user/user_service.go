@@ -1,79 +1,218 @@
 package user
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/logging"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound = apperror.New(apperror.NotFound, "user.not_found")
+	ErrEmailTaken   = apperror.New(apperror.Conflict, "user.email_taken")
 )
 
 type User struct {
-	id        string     `bson:"id"`
-	Name      string     `bson:"name"`
-	Email     string     `bson:"email"`
-	deletedAt *time.Time `bson:"deletedAt,omitempty"`
+	Id        string     `json:"id" bson:"id"`
+	Name      string     `json:"name" bson:"name"`
+	Email     string     `json:"email" bson:"email"`
+	CreatedAt time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
-func FindById(id string, db *sql.DB) (*User, error) {
-	var user User
+type CreateUserDto struct {
+	Name  string `json:"name" binding:"required,min=2,max=100"`
+	Email string `json:"email" binding:"required,email,max=255"`
+}
 
-	query := `
-		SELECT 
+type UpdateUserDto struct {
+	Name  *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
+}
+
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+const userColumns = `
 				id,
-				name, 
+				name,
+				email,
+				created_at,
+				updated_at,
 				deleted_at
+`
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	var user User
+	// Usuários das tabelas criadas antes da migration 0001 podem não ter email
+	var email sql.NullString
+
+	err := row.Scan(
+		&user.Id,
+		&user.Name,
+		&email,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.Email = email.String
+
+	return &user, nil
+}
+
+// FindById retorna nil quando o usuário não existe. Usuários removidos são
+// retornados com DeletedAt preenchido para que quem chama decida o que fazer.
+func FindById(ctx context.Context, id string, db *sql.DB) (*User, error) {
+	query := `
+		SELECT` + userColumns + `
 		FROM
 		 		users
 		WHERE
 				id = $1
 	`
 
-	err := db.QueryRow(query, id).Scan(
-		&user.id,
-		&user.Name,
-		&user.deletedAt,
-	)
+	user, err := scanUser(db.QueryRowContext(ctx, query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return &user, nil
+			return nil, nil
 		}
 
-		fmt.Printf("Erro encontrado: %v", err)
+		slog.ErrorContext(ctx, "Erro ao buscar usuário", logging.UserId(id), logging.Err(err))
 
-		return &user, err
+		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+func FindAll(ctx context.Context, limit int, offset int, db *sql.DB) ([]User, error) {
+	query := `
+		SELECT` + userColumns + `
+		FROM
+				users
+		WHERE
+				deleted_at IS NULL
+		ORDER BY
+				created_at
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := db.QueryContext(ctx, query, limit, offset)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao listar usuários", logging.Err(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []User{}
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Erro ao ler usuário", logging.Err(err))
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
 }
 
-// func findAll(db *sql.DB) ([]User, error) {
-// 	var users []User
+func Create(ctx context.Context, dto CreateUserDto, db *sql.DB) (*User, error) {
+	query := `
+		INSERT INTO users (name, email)
+		VALUES ($1, $2)
+		RETURNING` + userColumns
 
-// 	var query = `
-// 	SELECT name, email FROM users
-// 	`
+	user, err := scanUser(db.QueryRowContext(ctx, query, dto.Name, dto.Email))
 
-// 	rows, err := db.Query(query)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailTaken
+		}
 
-// 	if err != nil {
-// 		log.Printf("Erro na query de busca: %v", err)
-// 		return nil, err
-// 	}
+		slog.ErrorContext(ctx, "Erro ao criar usuário", logging.Err(err))
+		return nil, err
+	}
 
-// 	defer rows.Close()
+	return user, nil
+}
 
-// 	for rows.Next() {
-// 		var user User
+// Update altera apenas os campos enviados; usuários removidos não podem ser editados.
+func Update(ctx context.Context, id string, dto UpdateUserDto, db *sql.DB) (*User, error) {
+	query := `
+		UPDATE users
+		SET
+				name = COALESCE($2, name),
+				email = COALESCE($3, email),
+				updated_at = NOW()
+		WHERE
+				id = $1
+				AND deleted_at IS NULL
+		RETURNING` + userColumns
 
-// 		err := rows.Scan(&user.Name, &user.Email)
+	user, err := scanUser(db.QueryRowContext(ctx, query, id, dto.Name, dto.Email))
 
-// 		if err != nil {
-// 			log.Printf("Erro na query de busca: %v", err)
-// 			return nil, err
-// 		}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
 
-// 		users = append(users, user)
-// 	}
+		if isUniqueViolation(err) {
+			return nil, ErrEmailTaken
+		}
+
+		slog.ErrorContext(ctx, "Erro ao atualizar usuário", logging.UserId(id), logging.Err(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SoftDelete preenche deleted_at, o que também invalida as API keys do usuário.
+func SoftDelete(ctx context.Context, id string, db *sql.DB) error {
+	result, err := db.ExecContext(ctx, `
+		UPDATE users
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao remover usuário", logging.UserId(id), logging.Err(err))
+		return err
+	}
 
-// 	return users, nil
-// }
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
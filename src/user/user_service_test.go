@@ -0,0 +1,133 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/postgres/postgrestest"
+
+	"github.com/lib/pq"
+)
+
+const userId = "7f1c1b2e-8c5e-4a8a-9d0a-3f7c1e9b2a10"
+
+var columns = []string{"id", "name", "email", "created_at", "updated_at", "deleted_at"}
+
+func userRow(deletedAt any) []any {
+	now := time.Now()
+	return []any{userId, "Ana", "ana@example.com", now, now, deletedAt}
+}
+
+func TestFindById(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("FROM").Rows(columns, userRow(nil))
+	fake.Expect("FROM").Rows(columns, userRow(time.Now()))
+	fake.Expect("FROM")
+
+	user, err := FindById(t.Context(), userId, db)
+	if err != nil || user == nil || user.Email != "ana@example.com" || user.IsDeleted() {
+		t.Fatalf("FindById = %+v, %v", user, err)
+	}
+
+	// Removidos voltam marcados, para que quem chama decida o que fazer
+	deleted, err := FindById(t.Context(), userId, db)
+	if err != nil || deleted == nil || !deleted.IsDeleted() {
+		t.Errorf("deleted user = %+v, %v", deleted, err)
+	}
+
+	missing, err := FindById(t.Context(), userId, db)
+	if err != nil || missing != nil {
+		t.Errorf("missing user = %+v, %v, want nil without error", missing, err)
+	}
+}
+
+func TestFindByIdWithoutEmail(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	now := time.Now()
+	fake.Expect("FROM").Rows(columns, []any{userId, "Ana", nil, now, now, nil})
+
+	user, err := FindById(t.Context(), userId, db)
+	if err != nil || user == nil || user.Email != "" {
+		t.Fatalf("FindById = %+v, %v, want a user with an empty email", user, err)
+	}
+}
+
+func TestFindAllSkipsDeletedUsers(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	now := time.Now()
+	fake.Expect("LIMIT $1 OFFSET $2").Rows(columns, userRow(nil), []any{userId, "Legado", nil, now, now, nil})
+
+	users, err := FindAll(t.Context(), 20, 40, db)
+	if err != nil || len(users) != 2 || users[1].Email != "" {
+		t.Fatalf("FindAll = %+v, %v", users, err)
+	}
+
+	call := fake.Calls()[0]
+	if !strings.Contains(call.Query, "deleted_at IS NULL") || call.Args[0] != int64(20) || call.Args[1] != int64(40) {
+		t.Errorf("query = %s %v", call.Query, call.Args)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("INSERT INTO users").Rows(columns, userRow(nil))
+	fake.Expect("INSERT INTO users").Error(&pq.Error{Code: "23505"})
+
+	user, err := Create(t.Context(), CreateUserDto{Name: "Ana", Email: "ana@example.com"}, db)
+	if err != nil || user.Id != userId {
+		t.Fatalf("Create = %+v, %v", user, err)
+	}
+
+	if _, err := Create(t.Context(), CreateUserDto{Name: "Ana", Email: "ana@example.com"}, db); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("err = %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("UPDATE users").Rows(columns, userRow(nil))
+	fake.Expect("UPDATE users")
+	fake.Expect("UPDATE users").Error(&pq.Error{Code: "23505"})
+
+	name := "Ana Maria"
+
+	if _, err := Update(t.Context(), userId, UpdateUserDto{Name: &name}, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Campos omitidos vão como NULL e o COALESCE mantém o valor atual
+	call := fake.Calls()[0]
+	if call.Args[1] != name || call.Args[2] != nil || !strings.Contains(call.Query, "deleted_at IS NULL") {
+		t.Errorf("update = %s %v", call.Query, call.Args)
+	}
+
+	if _, err := Update(t.Context(), userId, UpdateUserDto{Name: &name}, db); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound for a missing or deleted user", err)
+	}
+
+	email := "taken@example.com"
+	if _, err := Update(t.Context(), userId, UpdateUserDto{Email: &email}, db); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("err = %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("SET deleted_at = NOW()").RowsAffected(1)
+	fake.Expect("SET deleted_at = NOW()").RowsAffected(0)
+
+	if err := SoftDelete(t.Context(), userId, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(fake.Calls()[0].Query, "deleted_at IS NULL") {
+		t.Error("soft delete would overwrite the original deleted_at")
+	}
+
+	// Já removido
+	if err := SoftDelete(t.Context(), userId, db); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,160 @@
+package workspace
+
+import (
+	"database/sql"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateWorkspaceDto struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+}
+
+type SetMemberDto struct {
+	Role Role `json:"role" binding:"required,oneof=owner editor analyst viewer"`
+}
+
+type WorkspaceController struct {
+	PostgresClient *sql.DB
+}
+
+// @Summary      Create a workspace
+// @Tags         Workspaces
+// @Accept       json
+// @Produce      json
+// @Param        request body workspace.CreateWorkspaceDto true "Workspace Payload"
+// @Success      201 {object} workspace.Workspace
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /workspaces [post]
+func (u *WorkspaceController) CreateWorkspace(c *gin.Context) {
+	var createWorkspaceDto CreateWorkspaceDto
+
+	err := c.ShouldBindJSON(&createWorkspaceDto)
+
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	principal, _ := auth.GetPrincipal(c)
+
+	workspace, err := Create(c.Request.Context(), createWorkspaceDto.Name, principal.UserId, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(201, workspace)
+}
+
+// @Summary      List the caller's workspaces
+// @Tags         Workspaces
+// @Produce      json
+// @Success      200 {array} workspace.Workspace
+// @Failure      401 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /workspaces [get]
+func (u *WorkspaceController) FindAllWorkspaces(c *gin.Context) {
+	principal, _ := auth.GetPrincipal(c)
+
+	workspaces, err := FindAllByUser(c.Request.Context(), principal.UserId, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, workspaces)
+}
+
+// @Summary      List workspace members
+// @Tags         Workspaces
+// @Produce      json
+// @Param        workspaceId path string true "Workspace ID"
+// @Success      200 {array} workspace.Member
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /workspaces/{workspaceId}/members [get]
+func (u *WorkspaceController) FindMembers(c *gin.Context) {
+	members, err := FindMembers(c.Request.Context(), c.Param("workspaceId"), u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, members)
+}
+
+// @Summary      Add a member or change their role
+// @Tags         Workspaces
+// @Accept       json
+// @Produce      json
+// @Param        workspaceId path string true "Workspace ID"
+// @Param        userId path string true "User ID"
+// @Param        request body workspace.SetMemberDto true "Member Role"
+// @Success      200 {object} workspace.Member
+// @Failure      400 {object} apperror.Problem
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /workspaces/{workspaceId}/members/{userId} [put]
+func (u *WorkspaceController) SetMember(c *gin.Context) {
+	var setMemberDto SetMemberDto
+
+	err := c.ShouldBindJSON(&setMemberDto)
+
+	if err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	member, err := SetMember(c.Request.Context(), c.Param("workspaceId"), c.Param("userId"), setMemberDto.Role, u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.IndentedJSON(200, member)
+}
+
+// @Summary      Remove a member
+// @Tags         Workspaces
+// @Param        workspaceId path string true "Workspace ID"
+// @Param        userId path string true "User ID"
+// @Success      204
+// @Failure      401 {object} apperror.Problem
+// @Failure      403 {object} apperror.Problem
+// @Failure      404 {object} apperror.Problem
+// @Failure      409 {object} apperror.Problem
+// @Failure      429 {object} apperror.Problem
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /workspaces/{workspaceId}/members/{userId} [delete]
+func (u *WorkspaceController) RemoveMember(c *gin.Context) {
+	err := RemoveMember(c.Request.Context(), c.Param("workspaceId"), c.Param("userId"), u.PostgresClient)
+
+	if err != nil {
+		apperror.Respond(c, err)
+		return
+	}
+
+	c.Status(204)
+}
@@ -0,0 +1,87 @@
+package workspace
+
+import (
+	"database/sql"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/postgres"
+
+	"github.com/gin-gonic/gin"
+)
+
+const memberKey = "workspaceMember"
+
+// RequirePermission verifica se o principal tem a permissão no workspace
+// indicado pelo parâmetro :workspaceId ou pelo header X-Workspace-ID.
+// Sem workspace na requisição a rota segue no escopo pessoal do usuário.
+func RequirePermission(db *sql.DB, permission Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceId := c.Param("workspaceId")
+		if workspaceId == "" {
+			workspaceId = c.GetHeader("X-Workspace-ID")
+		}
+
+		if workspaceId == "" {
+			c.Next()
+			return
+		}
+
+		// Um id que não é UUID não corresponde a nenhum workspace
+		if !postgres.IsUUID(workspaceId) {
+			apperror.Respond(c, ErrWorkspaceNotFound)
+			return
+		}
+
+		principal, ok := auth.GetPrincipal(c)
+
+		if !ok {
+			apperror.Respond(c, apperror.New(apperror.Unauthorized, "auth.required"))
+			return
+		}
+
+		// Admins atuam em qualquer workspace existente como owner
+		if principal.IsAdmin() {
+			exists, err := Exists(c.Request.Context(), workspaceId, db)
+
+			if err != nil {
+				apperror.Respond(c, err)
+				return
+			}
+
+			if !exists {
+				apperror.Respond(c, ErrWorkspaceNotFound)
+				return
+			}
+
+			c.Set(memberKey, &Member{WorkspaceId: workspaceId, UserId: principal.UserId, Role: RoleOwner})
+			c.Next()
+			return
+		}
+
+		member, err := FindMember(c.Request.Context(), workspaceId, principal.UserId, db)
+
+		if err != nil {
+			apperror.Respond(c, err)
+			return
+		}
+
+		if member == nil || !member.Role.Can(permission) {
+			apperror.Respond(c, apperror.New(apperror.Forbidden, "workspace.forbidden"))
+			return
+		}
+
+		c.Set(memberKey, member)
+		c.Next()
+	}
+}
+
+// CurrentMember retorna o vínculo validado por RequirePermission, se houver.
+func CurrentMember(c *gin.Context) (*Member, bool) {
+	value, ok := c.Get(memberKey)
+	if !ok {
+		return nil, false
+	}
+
+	member, ok := value.(*Member)
+	return member, ok
+}
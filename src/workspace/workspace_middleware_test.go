@@ -0,0 +1,128 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/auth"
+	"qr-code-boost/src/middlewares"
+	"qr-code-boost/src/postgres/postgrestest"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	workspaceId = "0b7c6d5e-4f3a-4b2c-9d1e-0f9a8b7c6d5e"
+	memberId    = "7f1c1b2e-8c5e-4a8a-9d0a-3f7c1e9b2a10"
+)
+
+var memberColumns = []string{"workspace_id", "user_id", "role", "created_at"}
+
+func TestRequirePermission(t *testing.T) {
+	member := &auth.Principal{UserId: memberId}
+	admin := &auth.Principal{UserId: "admin", Scopes: []string{auth.ScopeAdmin}}
+
+	cases := []struct {
+		name       string
+		principal  *auth.Principal
+		path       string
+		header     string
+		permission Permission
+		role       Role // vazio quando o usuário não é membro
+		queries    bool
+		lookup     bool // busca do workspace, feita só para admins
+		exists     bool
+		want       int
+		wantRole   Role
+	}{
+		{name: "personal scope", principal: member, path: "/qr", permission: PermissionQRCodeWrite, want: 200},
+		{name: "without principal", path: "/workspaces/" + workspaceId + "/qr", permission: PermissionQRCodeRead, want: 401},
+		{name: "admin acts as owner", principal: admin, path: "/workspaces/" + workspaceId + "/qr", permission: PermissionMembersManage, lookup: true, exists: true, want: 200, wantRole: RoleOwner},
+		{name: "admin in unknown workspace", principal: admin, path: "/workspaces/" + workspaceId + "/qr", permission: PermissionQRCodeRead, lookup: true, want: 404},
+		{name: "malformed id", principal: member, path: "/workspaces/not-a-uuid/qr", permission: PermissionQRCodeRead, want: 404},
+		{name: "malformed header", principal: admin, path: "/qr", header: "1; DROP TABLE", permission: PermissionQRCodeRead, want: 404},
+		{name: "not a member", principal: member, path: "/workspaces/" + workspaceId + "/qr", permission: PermissionQRCodeRead, queries: true, want: 403},
+		{name: "viewer cannot write", principal: member, path: "/workspaces/" + workspaceId + "/qr", permission: PermissionQRCodeWrite, role: RoleViewer, queries: true, want: 403},
+		{name: "analyst reads stats", principal: member, path: "/workspaces/" + workspaceId + "/qr", permission: PermissionStatsRead, role: RoleAnalyst, queries: true, want: 200, wantRole: RoleAnalyst},
+		{name: "editor writes by header", principal: member, path: "/qr", header: workspaceId, permission: PermissionQRCodeWrite, role: RoleEditor, queries: true, want: 200, wantRole: RoleEditor},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			db, fake := postgrestest.New(t)
+
+			var current *Member
+
+			router := gin.New()
+			router.Use(middlewares.RecoveryMiddleware(), func(c *gin.Context) {
+				if tc.principal != nil {
+					auth.SetPrincipal(c, tc.principal)
+				}
+				c.Next()
+			})
+
+			handler := func(c *gin.Context) {
+				current, _ = CurrentMember(c)
+				c.Status(http.StatusOK)
+			}
+			router.GET("/workspaces/:workspaceId/qr", RequirePermission(db, tc.permission), handler)
+			router.GET("/qr", RequirePermission(db, tc.permission), handler)
+
+			if tc.lookup {
+				fake.Expect("FROM workspaces").Rows([]string{"exists"}, []any{tc.exists})
+			}
+
+			if tc.queries {
+				expectation := fake.Expect("workspace_members")
+				if tc.role != "" {
+					expectation.Rows(memberColumns, []any{workspaceId, memberId, string(tc.role), time.Now()})
+				}
+			}
+
+			request := httptest.NewRequest("GET", tc.path, nil)
+			if tc.header != "" {
+				request.Header.Set("X-Workspace-ID", tc.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.want {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tc.want, recorder.Body)
+			}
+
+			switch {
+			case tc.wantRole == "" && current != nil:
+				t.Errorf("current member = %+v, want none", current)
+			case tc.wantRole != "" && (current == nil || current.Role != tc.wantRole || current.WorkspaceId != workspaceId):
+				t.Errorf("current member = %+v, want %s in %s", current, tc.wantRole, workspaceId)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionDatabaseError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, fake := postgrestest.New(t)
+	fake.Expect("workspace_members").Error(errors.New("connection refused"))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserId: memberId})
+		c.Next()
+	})
+	router.GET("/workspaces/:workspaceId/qr", RequirePermission(db, PermissionQRCodeRead), func(c *gin.Context) {
+		t.Error("handler ran after the membership lookup failed")
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/workspaces/"+workspaceId+"/qr", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", recorder.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package workspace
+
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleEditor  Role = "editor"
+	RoleAnalyst Role = "analyst"
+	RoleViewer  Role = "viewer"
+)
+
+type Permission string
+
+const (
+	PermissionQRCodeRead    Permission = "qrcode:read"
+	PermissionQRCodeWrite   Permission = "qrcode:write"
+	PermissionStatsRead     Permission = "stats:read"
+	PermissionMembersRead   Permission = "members:read"
+	PermissionMembersManage Permission = "members:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionQRCodeRead,
+		PermissionQRCodeWrite,
+		PermissionStatsRead,
+		PermissionMembersRead,
+		PermissionMembersManage,
+	},
+	RoleEditor: {
+		PermissionQRCodeRead,
+		PermissionQRCodeWrite,
+		PermissionStatsRead,
+		PermissionMembersRead,
+	},
+	RoleAnalyst: {
+		PermissionQRCodeRead,
+		PermissionStatsRead,
+		PermissionMembersRead,
+	},
+	RoleViewer: {
+		PermissionQRCodeRead,
+		PermissionMembersRead,
+	},
+}
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
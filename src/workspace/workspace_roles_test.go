@@ -0,0 +1,27 @@
+package workspace
+
+import "testing"
+
+func TestRolePermissions(t *testing.T) {
+	matrix := map[Role]map[Permission]bool{
+		RoleOwner:   {PermissionQRCodeRead: true, PermissionQRCodeWrite: true, PermissionStatsRead: true, PermissionMembersRead: true, PermissionMembersManage: true},
+		RoleEditor:  {PermissionQRCodeRead: true, PermissionQRCodeWrite: true, PermissionStatsRead: true, PermissionMembersRead: true},
+		RoleAnalyst: {PermissionQRCodeRead: true, PermissionStatsRead: true, PermissionMembersRead: true},
+		RoleViewer:  {PermissionQRCodeRead: true, PermissionMembersRead: true},
+		"guest":     {},
+	}
+
+	permissions := []Permission{PermissionQRCodeRead, PermissionQRCodeWrite, PermissionStatsRead, PermissionMembersRead, PermissionMembersManage}
+
+	for role, granted := range matrix {
+		if role.IsValid() != (role != "guest") {
+			t.Errorf("%s.IsValid() = %v", role, role.IsValid())
+		}
+
+		for _, permission := range permissions {
+			if got := role.Can(permission); got != granted[permission] {
+				t.Errorf("%s.Can(%s) = %v, want %v", role, permission, got, granted[permission])
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package workspace
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Workspace Routes
+func WorkspacesRouter(r *gin.Engine, workspaceController *WorkspaceController, guards ...gin.HandlerFunc) {
+	db := workspaceController.PostgresClient
+
+	workspaceRoutes := r.Group("/workspaces", guards...)
+	{
+		workspaceRoutes.POST("/", workspaceController.CreateWorkspace)
+		workspaceRoutes.GET("/", workspaceController.FindAllWorkspaces)
+		workspaceRoutes.GET("/:workspaceId/members", RequirePermission(db, PermissionMembersRead), workspaceController.FindMembers)
+		workspaceRoutes.PUT("/:workspaceId/members/:userId", RequirePermission(db, PermissionMembersManage), workspaceController.SetMember)
+		workspaceRoutes.DELETE("/:workspaceId/members/:userId", RequirePermission(db, PermissionMembersManage), workspaceController.RemoveMember)
+	}
+}
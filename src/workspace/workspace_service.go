@@ -0,0 +1,303 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"qr-code-boost/src/apperror"
+	"qr-code-boost/src/logging"
+	"time"
+)
+
+var (
+	ErrLastOwner         = apperror.New(apperror.Conflict, "workspace.last_owner")
+	ErrWorkspaceNotFound = apperror.New(apperror.NotFound, "workspace.not_found")
+)
+
+type Workspace struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Member struct {
+	WorkspaceId string    `json:"workspaceId"`
+	UserId      string    `json:"userId"`
+	Role        Role      `json:"role"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Create cria o workspace e adiciona quem o criou como owner na mesma transação.
+func Create(ctx context.Context, name string, ownerId string, db *sql.DB) (Workspace, error) {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	defer tx.Rollback()
+
+	workspace := Workspace{Name: name}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO workspaces (name)
+		VALUES ($1)
+		RETURNING id, created_at
+	`, name).Scan(&workspace.Id, &workspace.CreatedAt)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao criar workspace", logging.Err(err))
+		return Workspace{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`, workspace.Id, ownerId, RoleOwner)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao adicionar owner ao workspace", logging.WorkspaceId(workspace.Id), logging.UserId(ownerId), logging.Err(err))
+		return Workspace{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Workspace{}, err
+	}
+
+	return workspace, nil
+}
+
+func FindAllByUser(ctx context.Context, userId string, db *sql.DB) ([]Workspace, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+				w.id,
+				w.name,
+				w.created_at
+		FROM
+				workspaces w
+				JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE
+				m.user_id = $1
+		ORDER BY
+				w.created_at
+	`, userId)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao listar workspaces", logging.UserId(userId), logging.Err(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	workspaces := []Workspace{}
+
+	for rows.Next() {
+		var workspace Workspace
+
+		if err := rows.Scan(&workspace.Id, &workspace.Name, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+func Exists(ctx context.Context, id string, db *sql.DB) (bool, error) {
+	var exists bool
+
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM workspaces WHERE id = $1
+		)
+	`, id).Scan(&exists)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao buscar workspace", logging.WorkspaceId(id), logging.Err(err))
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// FindMember retorna nil quando o usuário não participa do workspace.
+func FindMember(ctx context.Context, workspaceId string, userId string, db *sql.DB) (*Member, error) {
+	var member Member
+
+	err := db.QueryRowContext(ctx, `
+		SELECT
+				workspace_id,
+				user_id,
+				role,
+				created_at
+		FROM
+				workspace_members
+		WHERE
+				workspace_id = $1
+				AND user_id = $2
+	`, workspaceId, userId).Scan(
+		&member.WorkspaceId,
+		&member.UserId,
+		&member.Role,
+		&member.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		slog.ErrorContext(ctx, "Erro ao buscar membro do workspace", logging.WorkspaceId(workspaceId), logging.UserId(userId), logging.Err(err))
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func FindMembers(ctx context.Context, workspaceId string, db *sql.DB) ([]Member, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+				workspace_id,
+				user_id,
+				role,
+				created_at
+		FROM
+				workspace_members
+		WHERE
+				workspace_id = $1
+		ORDER BY
+				created_at
+	`, workspaceId)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao listar membros do workspace", logging.WorkspaceId(workspaceId), logging.Err(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := []Member{}
+
+	for rows.Next() {
+		var member Member
+
+		if err := rows.Scan(&member.WorkspaceId, &member.UserId, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetMember adiciona o usuário ao workspace ou altera o papel dele.
+func SetMember(ctx context.Context, workspaceId string, userId string, role Role, db *sql.DB) (Member, error) {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return Member{}, err
+	}
+
+	defer tx.Rollback()
+
+	if role != RoleOwner {
+		if err := ensureAnotherOwner(ctx, workspaceId, userId, tx); err != nil {
+			return Member{}, err
+		}
+	}
+
+	member := Member{WorkspaceId: workspaceId, UserId: userId, Role: role}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING created_at
+	`, workspaceId, userId, role).Scan(&member.CreatedAt)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao salvar membro do workspace", logging.WorkspaceId(workspaceId), logging.UserId(userId), logging.Err(err))
+		return Member{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Member{}, err
+	}
+
+	return member, nil
+}
+
+func RemoveMember(ctx context.Context, workspaceId string, userId string, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := ensureAnotherOwner(ctx, workspaceId, userId, tx); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceId, userId)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao remover membro do workspace", logging.WorkspaceId(workspaceId), logging.UserId(userId), logging.Err(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ensureAnotherOwner impede que o último owner seja removido ou rebaixado. Os
+// owners ficam travados até o fim da transação: duas alterações simultâneas
+// não podem contar uma com o owner que a outra está rebaixando, pois a segunda
+// só lê os owners depois que a primeira termina.
+func ensureAnotherOwner(ctx context.Context, workspaceId string, userId string, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id
+		FROM workspace_members
+		WHERE workspace_id = $1 AND role = $2
+		FOR UPDATE
+	`, workspaceId, RoleOwner)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao buscar owners do workspace", logging.WorkspaceId(workspaceId), logging.Err(err))
+		return err
+	}
+
+	defer rows.Close()
+
+	isOwner := false
+	otherOwners := 0
+
+	for rows.Next() {
+		var ownerId string
+
+		if err := rows.Scan(&ownerId); err != nil {
+			return err
+		}
+
+		if ownerId == userId {
+			isOwner = true
+		} else {
+			otherOwners++
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if isOwner && otherOwners == 0 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"qr-code-boost/src/postgres/postgrestest"
+)
+
+const otherOwnerId = "5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d"
+
+var ownerColumns = []string{"user_id"}
+
+func TestSetMemberKeepsLastOwner(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("FOR UPDATE").Rows(ownerColumns, []any{memberId})
+
+	_, err := SetMember(t.Context(), workspaceId, memberId, RoleEditor, db)
+
+	if !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("err = %v, want ErrLastOwner", err)
+	}
+
+	if calls := fake.Calls(); !calls[0].InTx || fake.Commits() != 0 || fake.Rollbacks() != 1 {
+		t.Errorf("owner lock outside a rolled back transaction: %+v, %d commits", calls, fake.Commits())
+	}
+}
+
+func TestSetMemberDemotesOwnerWhenAnotherRemains(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("FOR UPDATE").Rows(ownerColumns, []any{memberId}, []any{otherOwnerId})
+	fake.Expect("ON CONFLICT").Rows([]string{"created_at"}, []any{time.Now()})
+
+	member, err := SetMember(t.Context(), workspaceId, memberId, RoleEditor, db)
+
+	if err != nil || member.Role != RoleEditor {
+		t.Fatalf("SetMember = %+v, %v", member, err)
+	}
+
+	for _, call := range fake.Calls() {
+		if !call.InTx {
+			t.Errorf("query outside the transaction: %s", call.Query)
+		}
+	}
+
+	if fake.Commits() != 1 {
+		t.Errorf("commits = %d, want 1", fake.Commits())
+	}
+}
+
+func TestSetMemberAsOwnerSkipsTheCheck(t *testing.T) {
+	db, fake := postgrestest.New(t)
+	fake.Expect("ON CONFLICT").Rows([]string{"created_at"}, []any{time.Now()})
+
+	if _, err := SetMember(t.Context(), workspaceId, memberId, RoleOwner, db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	cases := []struct {
+		name   string
+		owners [][]any
+		want   error
+	}{
+		{"last owner", [][]any{{memberId}}, ErrLastOwner},
+		{"one of two owners", [][]any{{memberId}, {otherOwnerId}}, nil},
+		{"not an owner", [][]any{{otherOwnerId}}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, fake := postgrestest.New(t)
+			fake.Expect("FOR UPDATE").Rows(ownerColumns, tc.owners...)
+
+			if tc.want == nil {
+				fake.Expect("DELETE FROM workspace_members").RowsAffected(1)
+			}
+
+			if err := RemoveMember(t.Context(), workspaceId, memberId, db); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+
+			calls := fake.Calls()
+			if !strings.Contains(calls[0].Query, "role = $2") || calls[0].Args[1] != string(RoleOwner) {
+				t.Errorf("owner query = %s %v", calls[0].Query, calls[0].Args)
+			}
+
+			if committed := fake.Commits() == 1; committed != (tc.want == nil) {
+				t.Errorf("commits = %d with err %v", fake.Commits(), tc.want)
+			}
+		})
+	}
+}